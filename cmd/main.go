@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -18,6 +19,9 @@ var (
 	port      = flag.Int("port", 50052, "Port of the service")
 	file      = flag.String("file", "sample_queue.json", "File containing queue of songs")
 	queueSize = flag.Int("queueSize", 3, "Number of songs to be queued")
+	name      = flag.String("name", "sample", "Controller name")
+	takeover  = flag.Bool("takeover", false, "Whether or not to take over from an existing primary")
+	heartbeat = flag.Int("heartbeat", 10, "Time between heartbeats, in seconds. Must be shorter than the server's lease timeout")
 )
 
 type Song struct {
@@ -49,15 +53,41 @@ func main() {
 	checkErr(err)
 	defer conn.Close()
 
-	c := playsource.NewPlaySourceClient(conn)
+	c := playsource.NewPlaysourceClient(conn)
 	stream, err := c.QueueSong(context.Background())
 	checkErr(err)
 
+	err = stream.Send(&playsource.QueueSongRequest{
+		Handshake: &playsource.Handshake{
+			Controller: *name,
+			Takeover:   *takeover,
+		},
+	})
+	checkErr(err)
+
+	resp, err := stream.Recv()
+	checkErr(err)
+	log.Printf("Acquired lease: %v", resp.Lease)
+
+	// Responses are received in the background, so that the lease
+	// can be kept with heartbeats while waiting on songs to finish.
+	responses := make(chan *playsource.QueueSongResponse)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			checkErr(err)
+			responses <- resp
+		}
+	}()
+
+	ticker := time.NewTicker(time.Duration(*heartbeat) * time.Second)
+	defer ticker.Stop()
+
 	// Try to play all the songs listed in the queue file using
 	// the proper protocol. Note: doesn't handle retries.
 	var inFlight int
 	for i := 0; i < len(songs); {
-		for inFlight < *queueSize {
+		for inFlight < *queueSize && i < len(songs) {
 			log.Println("Queueing song:", songs[i])
 			err := stream.Send(&playsource.QueueSongRequest{
 				Song: &playsource.Song{
@@ -72,8 +102,14 @@ func main() {
 			inFlight++
 		}
 
-		resp, err := stream.Recv()
-		checkErr(err)
+		var resp *playsource.QueueSongResponse
+		select {
+		case <-ticker.C:
+			// Requests without a song are heartbeats.
+			checkErr(stream.Send(&playsource.QueueSongRequest{}))
+			continue
+		case resp = <-responses:
+		}
 
 		inFlight--
 		if inFlight < 0 {
@@ -82,6 +118,8 @@ func main() {
 
 		if resp.Finished {
 			log.Println("Finished:", songs[resp.SongId])
+		} else if resp.Skipped {
+			log.Println("Skipped:", songs[resp.SongId])
 		} else if !resp.Found {
 			log.Println("Not Found:", songs[resp.SongId])
 		} else if !resp.Queued {
@@ -8,8 +8,11 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/crowdsoundsystem/playsource/pkg/history"
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
 	"github.com/crowdsoundsystem/playsource/pkg/playsource"
 	"github.com/crowdsoundsystem/playsource/pkg/server"
 	"github.com/crowdsoundsystem/playsource/pkg/systemd"
@@ -18,25 +21,96 @@ import (
 )
 
 var (
-	configPath   = flag.String("config", "", "Configuration path")
-	mopidyUrl    = flag.String("mopidyUrl", "http://localhost:6680/mopidy/rpc", "Mopidy RPC endpoint")
-	port         = flag.Int("port", 50052, "Port to listen on")
-	queueSize    = flag.Int("queueSize", 200, "Anticipated client queue size")
-	pollInterval = flag.Int("pollInterval", 10, "Mopidy poll time in seconds")
-	test         = flag.Bool("test", false, "Whether or not to emulate a real server")
-	serviceMode  = flag.Bool("serviceMode", false, "Whether or not the playsource is being run as a systemd service")
+	configPath          = flag.String("config", "", "Configuration path")
+	mopidyUrl           = flag.String("mopidyUrl", "http://localhost:6680/mopidy/rpc", "Mopidy RPC endpoint")
+	port                = flag.Int("port", 50052, "Port to listen on")
+	queueSize           = flag.Int("queueSize", 200, "Anticipated client queue size")
+	pollInterval        = flag.Int("pollInterval", 10, "Mopidy poll time in seconds")
+	mopidyTimeout       = flag.Int("mopidyTimeout", 10, "Time after which a mopidy request is abandoned, in seconds")
+	mopidyRetries       = flag.Int("mopidyRetries", 2, "Times to retry a request that couldn't reach mopidy")
+	mopidyRetryBackoff  = flag.Int("mopidyRetryBackoff", 100, "Time to wait before first retrying a mopidy request, in milliseconds")
+	historyPath         = flag.String("historyPath", "playsource_history.db", "Path of the play history database")
+	minConfidence       = flag.Float64("minConfidence", 0.6, "Minimum confidence for a track to match a song")
+	preferredBackends   = flag.String("preferredBackends", "local,spotify", "Comma separated mopidy backends, in order of preference")
+	resolveParallelism  = flag.Int("resolveParallelism", 4, "Number of songs to search for at once")
+	searchCacheTTL      = flag.Int("searchCacheTTL", 3600, "Time to cache search results for, in seconds")
+	searchCachePath     = flag.String("searchCachePath", "", "Path to persist search results at, if any")
+	leaseTimeout        = flag.Int("leaseTimeout", 30, "Time after which a silent primary loses its lease, in seconds")
+	volumeVoteThreshold = flag.Int("volumeVoteThreshold", 3, "Net votes needed to change the volume")
+	volumeVoteStep      = flag.Int("volumeVoteStep", 10, "Amount a volume vote changes the volume by")
+	volumeVoteWindow    = flag.Int("volumeVoteWindow", 60, "Time until a volume vote expires, in seconds")
+	skipVoteThreshold   = flag.Int("skipVoteThreshold", 0, "Distinct requesters needed to skip a song, or 0 to skip on request")
+	skipVoteWindow      = flag.Int("skipVoteWindow", 60, "Time until a skip vote expires, in seconds")
+	crossfade           = flag.Int("crossfade", 0, "Time to fade between songs over, in milliseconds")
+	skipFade            = flag.Int("skipFade", 0, "Time to fade skipped songs out over, in milliseconds")
+	test                = flag.Bool("test", false, "Whether or not to emulate a real server")
+	foundProbability    = flag.Float64("testFoundProbability", 1, "Probability that an emulated song is found")
+	failureProbability  = flag.Float64("testFailureProbability", 0, "Probability that an emulated song fails to play")
+	songLength          = flag.Int("testSongLength", 120, "Mean length of emulated songs, in seconds")
+	songLengthStdDev    = flag.Int("testSongLengthStdDev", 30, "Standard deviation of emulated song lengths, in seconds")
+	latency             = flag.Int("testLatency", 0, "Latency of emulated requests, in milliseconds")
+	serviceMode         = flag.Bool("serviceMode", false, "Whether or not the playsource is being run as a systemd service")
 )
 
 type Config struct {
-	MopidyURL    string `json:"mopidy_url"`
-	Port         int    `json:"port"`
-	QueueSize    int    `json:"queue_size"`
-	PollInterval int    `json:"poll_interval"`
-	Test         bool   `json:"test"`
+	MopidyURL     string `json:"mopidy_url"`
+	Port          int    `json:"port"`
+	QueueSize     int    `json:"queue_size"`
+	PollInterval  int    `json:"poll_interval"`
+	MopidyTimeout int    `json:"mopidy_timeout"`
+	HistoryPath   string `json:"history_path"`
+	Test          bool   `json:"test"`
+
+	MopidyRetries      int `json:"mopidy_retries"`
+	MopidyRetryBackoff int `json:"mopidy_retry_backoff"`
+
+	MinConfidence     float64  `json:"min_confidence"`
+	PreferredBackends []string `json:"preferred_backends"`
+
+	ResolveParallelism int    `json:"resolve_parallelism"`
+	SearchCacheTTL     int    `json:"search_cache_ttl"`
+	SearchCachePath    string `json:"search_cache_path"`
+
+	LeaseTimeout int `json:"lease_timeout"`
+
+	VolumeVoteThreshold int `json:"volume_vote_threshold"`
+	VolumeVoteStep      int `json:"volume_vote_step"`
+	VolumeVoteWindow    int `json:"volume_vote_window"`
+
+	SkipVoteThreshold int `json:"skip_vote_threshold"`
+	SkipVoteWindow    int `json:"skip_vote_window"`
+
+	Crossfade int `json:"crossfade"`
+	SkipFade  int `json:"skip_fade"`
 }
 
 func loadConfig() Config {
-	var config Config
+	// Flags provide the defaults for anything the config file omits.
+	config := Config{
+		MopidyURL:           *mopidyUrl,
+		Port:                *port,
+		QueueSize:           *queueSize,
+		PollInterval:        *pollInterval,
+		MopidyTimeout:       *mopidyTimeout,
+		MopidyRetries:       *mopidyRetries,
+		MopidyRetryBackoff:  *mopidyRetryBackoff,
+		HistoryPath:         *historyPath,
+		Test:                *test,
+		MinConfidence:       *minConfidence,
+		PreferredBackends:   strings.Split(*preferredBackends, ","),
+		ResolveParallelism:  *resolveParallelism,
+		SearchCacheTTL:      *searchCacheTTL,
+		SearchCachePath:     *searchCachePath,
+		LeaseTimeout:        *leaseTimeout,
+		VolumeVoteThreshold: *volumeVoteThreshold,
+		VolumeVoteStep:      *volumeVoteStep,
+		VolumeVoteWindow:    *volumeVoteWindow,
+		SkipVoteThreshold:   *skipVoteThreshold,
+		SkipVoteWindow:      *skipVoteWindow,
+		Crossfade:           *crossfade,
+		SkipFade:            *skipFade,
+	}
+
 	if *configPath != "" {
 		f, err := os.Open(*configPath)
 		if err != nil {
@@ -48,12 +122,13 @@ func loadConfig() Config {
 		if err != nil {
 			log.Fatal(err)
 		}
-	} else {
-		config.MopidyURL = *mopidyUrl
-		config.Port = *port
-		config.QueueSize = *queueSize
-		config.PollInterval = *pollInterval
-		config.Test = *test
+	}
+
+	if config.PollInterval <= 0 {
+		log.Fatal("poll_interval must be positive")
+	}
+	if config.LeaseTimeout <= 0 {
+		log.Fatal("lease_timeout must be positive")
 	}
 
 	return config
@@ -83,23 +158,80 @@ func main() {
 	grpcServer := grpc.NewServer()
 
 	if config.Test {
-		playsource.RegisterPlaysourceServer(
-			grpcServer,
-			server.NewTestServer(
-				config.QueueSize,
-				1.1,
-				120*time.Second,
-			),
-		)
+		testServer := server.NewTestServer(server.TestConfig{
+			MaxQueueSize:     config.QueueSize,
+			SongLength:       time.Duration(*songLength) * time.Second,
+			SongLengthStdDev: time.Duration(*songLengthStdDev) * time.Second,
+			Faults: playsource.Faults{
+				FoundProbability:   *foundProbability,
+				FailureProbability: *failureProbability,
+				LatencyMs:          int32(*latency),
+			},
+			LeaseTimeout: time.Duration(config.LeaseTimeout) * time.Second,
+			VolumeVote: server.VolumeVoteConfig{
+				Threshold: config.VolumeVoteThreshold,
+				Step:      config.VolumeVoteStep,
+				Window:    time.Duration(config.VolumeVoteWindow) * time.Second,
+			},
+			SkipVote: server.SkipVoteConfig{
+				Threshold: config.SkipVoteThreshold,
+				Window:    time.Duration(config.SkipVoteWindow) * time.Second,
+			},
+		})
+
+		playsource.RegisterPlaysourceServer(grpcServer, testServer)
+		playsource.RegisterTestControlServer(grpcServer, testServer)
 	} else {
-		playsource.RegisterPlaysourceServer(
-			grpcServer,
-			server.NewMopidyServer(
-				config.MopidyURL,
-				config.QueueSize,
-				time.Duration(config.PollInterval)*time.Second,
-			),
-		)
+		store, err := history.NewBoltStore(config.HistoryPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+
+		ttl := time.Duration(config.SearchCacheTTL) * time.Second
+		cache := server.NewSearchCache(ttl, server.RealClock)
+		if config.SearchCachePath != "" {
+			cache, err = server.OpenSearchCache(config.SearchCachePath, ttl, server.RealClock)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		defer cache.Close()
+
+		retry := mopidy.RetryPolicy{
+			Attempts:   config.MopidyRetries + 1,
+			Backoff:    time.Duration(config.MopidyRetryBackoff) * time.Millisecond,
+			MaxBackoff: mopidy.DefaultRetryPolicy.MaxBackoff,
+		}
+
+		mopidyServer := server.NewMopidyServer(server.MopidyConfig{
+			URL:                config.MopidyURL,
+			MaxQueueSize:       config.QueueSize,
+			PollInterval:       time.Duration(config.PollInterval) * time.Second,
+			RequestTimeout:     time.Duration(config.MopidyTimeout) * time.Second,
+			Retry:              retry,
+			History:            store,
+			Matcher:            server.NewScoringMatcher(config.MinConfidence, config.PreferredBackends),
+			SearchCache:        cache,
+			ResolveParallelism: config.ResolveParallelism,
+			LeaseTimeout:       time.Duration(config.LeaseTimeout) * time.Second,
+			VolumeVote: server.VolumeVoteConfig{
+				Threshold: config.VolumeVoteThreshold,
+				Step:      config.VolumeVoteStep,
+				Window:    time.Duration(config.VolumeVoteWindow) * time.Second,
+			},
+			SkipVote: server.SkipVoteConfig{
+				Threshold: config.SkipVoteThreshold,
+				Window:    time.Duration(config.SkipVoteWindow) * time.Second,
+			},
+			Playback: server.PlaybackConfig{
+				Crossfade: time.Duration(config.Crossfade) * time.Millisecond,
+				SkipFade:  time.Duration(config.SkipFade) * time.Millisecond,
+			},
+		})
+		defer mopidyServer.Close()
+
+		playsource.RegisterPlaysourceServer(grpcServer, mopidyServer)
 	}
 
 	if *serviceMode {
@@ -0,0 +1,126 @@
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var historyBucket = []byte("history")
+
+// BoltStore is a Store that persists history in a bolt database,
+// so that it survives restarts.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(historyBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Keys are the big endian start time followed by a sequence number,
+// so that a cursor walks entries in the order they started, and two
+// entries with the same start time don't collide.
+func entryKey(started time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[0:8], uint64(started.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:16], seq)
+	return key
+}
+
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+func (b *BoltStore) Record(entry Entry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket)
+
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(entryKey(entry.Started, seq), value)
+	})
+}
+
+func (b *BoltStore) Query(q Query) ([]Entry, error) {
+	entries := make([]Entry, 0)
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(historyBucket).Cursor()
+
+		// Walk backwards from the upper bound, so that the limit
+		// keeps the most recent entries.
+		var k, v []byte
+		if q.Until.IsZero() {
+			k, v = c.Last()
+		} else {
+			k, v = c.Seek(timeKey(q.Until))
+			if k == nil {
+				k, v = c.Last()
+			}
+			for k != nil && bytes.Compare(k[0:8], timeKey(q.Until)) >= 0 {
+				k, v = c.Prev()
+			}
+		}
+
+		for ; k != nil; k, v = c.Prev() {
+			if q.Limit > 0 && len(entries) >= q.Limit {
+				break
+			}
+
+			var e Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+
+			if !q.Since.IsZero() && e.Started.Before(q.Since) {
+				break
+			}
+
+			entries = append(entries, e)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Restore chronological order.
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	return entries, nil
+}
+
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,69 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltStoreQuery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "history.db")
+	store, err := NewBoltStore(path)
+	require.NoError(t, err)
+
+	start := time.Unix(1000, 0)
+	for i := 0; i < 10; i++ {
+		err := store.Record(Entry{
+			Song:     playsource.Song{SongId: int32(i)},
+			Started:  start.Add(time.Duration(i) * time.Minute),
+			Finished: start.Add(time.Duration(i+1) * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	// History should survive reopening the store.
+	require.NoError(t, store.Close())
+	store, err = NewBoltStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	ids := func(entries []Entry) (ids []int32) {
+		for _, e := range entries {
+			ids = append(ids, e.Song.SongId)
+		}
+		return ids
+	}
+
+	entries, err := store.Query(Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, ids(entries))
+
+	entries, err = store.Query(Query{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{7, 8, 9}, ids(entries))
+
+	entries, err = store.Query(Query{
+		Since: start.Add(2 * time.Minute),
+		Until: start.Add(5 * time.Minute),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{2, 3, 4}, ids(entries))
+
+	entries, err = store.Query(Query{
+		Since: start.Add(2 * time.Minute),
+		Until: start.Add(5 * time.Minute),
+		Limit: 2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{3, 4}, ids(entries))
+}
@@ -0,0 +1,104 @@
+package history
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+// Entry is a single song that was played by the playsource.
+type Entry struct {
+	Song playsource.Song `json:"song"`
+
+	// URI of the track that was actually played for the song.
+	URI string `json:"uri"`
+
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+
+	// Whether or not the song was skipped before it finished.
+	Skipped bool `json:"skipped"`
+}
+
+// Query filters the entries returned from a Store. Zero values
+// mean no filtering.
+type Query struct {
+	// Only include entries that started at or after Since.
+	Since time.Time
+
+	// Only include entries that started before Until.
+	Until time.Time
+
+	// Only include the most recent Limit entries.
+	Limit int
+}
+
+func (q Query) matches(e Entry) bool {
+	if !q.Since.IsZero() && e.Started.Before(q.Since) {
+		return false
+	}
+
+	if !q.Until.IsZero() && !e.Started.Before(q.Until) {
+		return false
+	}
+
+	return true
+}
+
+// Store records play history. Entries are returned in the order
+// they started playing.
+type Store interface {
+	Record(entry Entry) error
+	Query(q Query) ([]Entry, error)
+	Close() error
+}
+
+// MemoryStore is a Store that does not persist across restarts.
+type MemoryStore struct {
+	lock    sync.Mutex
+	entries []Entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (m *MemoryStore) Record(entry Entry) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Entries are usually recorded in order, so this is cheap.
+	i := sort.Search(len(m.entries), func(i int) bool {
+		return m.entries[i].Started.After(entry.Started)
+	})
+
+	m.entries = append(m.entries, Entry{})
+	copy(m.entries[i+1:], m.entries[i:])
+	m.entries[i] = entry
+
+	return nil
+}
+
+func (m *MemoryStore) Query(q Query) ([]Entry, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	entries := make([]Entry, 0)
+	for _, e := range m.entries {
+		if q.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[len(entries)-q.Limit:]
+	}
+
+	return entries, nil
+}
+
+func (m *MemoryStore) Close() error {
+	return nil
+}
@@ -6,71 +6,195 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
 )
 
 var (
-	idCounter      = 0
 	jsonRPCVersion = "2.0"
 )
 
+// DefaultTimeout bounds each request made by a Client from NewClient.
+const DefaultTimeout = 10 * time.Second
+
+// PlayState is the state of mopidy's playback. It's encoded in JSON as
+// mopidy names it, e.g. "paused".
 type PlayState int
 
 const (
-	Unknown = iota
+	Unknown PlayState = iota
 	Playing
 	Paused
 	Stopped
 )
 
+var playStateNames = map[PlayState]string{
+	Playing: "playing",
+	Paused:  "paused",
+	Stopped: "stopped",
+}
+
+func (s PlayState) String() string {
+	if name, ok := playStateNames[s]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+func (s PlayState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON decodes a state named by mopidy. States we don't
+// know of are Unknown.
+func (s *PlayState) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+
+	*s = Unknown
+	for state, n := range playStateNames {
+		if n == name {
+			*s = state
+		}
+	}
+
+	return nil
+}
+
+// Client calls mopidy's JSON-RPC API. Every request is bounded by both
+// the context it's made with, and the timeout of the http.Client.
+//
+// Errors from mopidy are a *TransportError, *ProtocolError,
+// *MethodNotFoundError or *BackendError, unless the context
+// was done first, in which case its error is returned.
 type Client struct {
-	url string
+	// Used atomically, so it's first to keep it 64-bit aligned.
+	nextID int64
+
+	url        string
+	httpClient *http.Client
+	retry      RetryPolicy
 }
 
 func NewClient(url string) *Client {
-	return &Client{url: url}
+	return NewClientWithHTTPClient(url, &http.Client{Timeout: DefaultTimeout})
+}
+
+// NewClientWithHTTPClient returns a Client that makes its requests with
+// httpClient, say to configure its timeouts.
+func NewClientWithHTTPClient(url string, httpClient *http.Client) *Client {
+	return &Client{url: url, httpClient: httpClient, retry: DefaultRetryPolicy}
+}
+
+// SetRetryPolicy changes how requests that don't reach mopidy are
+// retried. It must be called before the Client is used.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
 }
 
 type mopidyRequest struct {
 	Method  string `json:"method"`
 	Version string `json:"jsonrpc"`
-	ID      int    `json:"id"`
+	ID      int64  `json:"id"`
 
 	Params interface{} `json:"params"`
 }
 
 type modipyResponse struct {
-	Result      json.RawMessage `json:"result"`
-	ErrorResult struct {
-		Message string `json:"message"`
-		Code    int    `json:"code"`
-		Data    struct {
-			Message string
-		} `json:"data"`
-	} `json:"error"`
+	ID     *int64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Err    *rpcError       `json:"error"`
 }
 
-func (m *modipyResponse) Error() error {
-	return fmt.Errorf("code = %v, message = %v", m.ErrorResult.Code, m.ErrorResult.Message)
+// check returns an error if m isn't a successful response to the
+// request with the given id, which called method.
+func (m *modipyResponse) check(method string, id int64) error {
+	// Errors about the request as a whole, say if it couldn't be
+	// parsed, don't have an id.
+	if m.ID == nil && m.Err != nil {
+		return m.Err.typed(method)
+	}
+
+	if m.ID == nil || *m.ID != id {
+		return &ProtocolError{Message: fmt.Sprintf("response is not for request %v", id)}
+	}
+
+	if m.Err != nil {
+		return m.Err.typed(method)
+	}
+
+	return nil
 }
 
-func (c *Client) request(method string, params interface{}) (response *modipyResponse, err error) {
-	body, err := json.Marshal(mopidyRequest{
+func (c *Client) newRequest(method string, params interface{}) mopidyRequest {
+	return mopidyRequest{
 		Method:  method,
 		Version: jsonRPCVersion,
-		ID:      idCounter,
+		ID:      atomic.AddInt64(&c.nextID, 1),
 		Params:  params,
-	})
+	}
+}
+
+// post sends body to mopidy, and returns the body of the response.
+// It's retried according to the Client's RetryPolicy.
+func (c *Client) post(ctx context.Context, body interface{}) (response []byte, err error) {
+	b, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.Post(c.url, "application/json", bytes.NewReader(body))
+	err = c.retry.Do(ctx, func() error {
+		response, err = c.postOnce(ctx, b)
+		return err
+	})
+	return response, err
+}
+
+func (c *Client) postOnce(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var reused, written bool
+	trace := &httptrace.ClientTrace{
+		GotConn:      func(info httptrace.GotConnInfo) { reused = info.Reused },
+		WroteRequest: func(info httptrace.WroteRequestInfo) { written = info.Err == nil },
+	}
+
+	resp, err := ctxhttp.Do(httptrace.WithClientTrace(ctx, trace), c.httpClient, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, transportError(err, reused, written)
+	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp.StatusCode)
+	}
+
 	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, transportError(err, reused, written)
+	}
+
+	return b, nil
+}
+
+func (c *Client) request(ctx context.Context, method string, params interface{}) (response *modipyResponse, err error) {
+	request := c.newRequest(method, params)
+
+	b, err := c.post(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -78,157 +202,470 @@ func (c *Client) request(method string, params interface{}) (response *modipyRes
 	response = new(modipyResponse)
 	err = json.Unmarshal(b, response)
 	if err != nil {
-		return nil, err
+		return nil, &ProtocolError{Message: err.Error()}
 	}
 
-	if response.ErrorResult.Code != 0 {
-		return nil, response.Error()
+	if err = response.check(method, request.ID); err != nil {
+		return nil, err
 	}
 
 	return response, nil
 }
 
+// decodeResult unmarshals the result of a request into v.
+func decodeResult(result json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(result, v); err != nil {
+		return &ProtocolError{Message: err.Error()}
+	}
+
+	return nil
+}
+
+// Batch is a set of calls that are sent to mopidy in a single request.
+// Results are stored as the calls are added, once the batch is sent
+// with Client.Batch.
+type Batch struct {
+	calls []batchCall
+}
+
+type batchCall struct {
+	method string
+	params interface{}
+	decode func(result json.RawMessage) error
+}
+
+// Call adds a call of method to the batch, whose result is
+// unmarshaled into result.
+func (b *Batch) Call(method string, params interface{}, result interface{}) {
+	b.calls = append(b.calls, batchCall{
+		method: method,
+		params: params,
+		decode: func(r json.RawMessage) error { return decodeResult(r, result) },
+	})
+}
+
+// CurrentState adds a call that stores the playback state in state.
+func (b *Batch) CurrentState(state *PlayState) {
+	b.Call("core.playback.get_state", struct{}{}, state)
+}
+
+// CurrentTlTrack adds a call that stores the tracklist entry that is
+// playing in tlTrack, or nil if nothing is.
+func (b *Batch) CurrentTlTrack(tlTrack **TlTrack) {
+	b.Call("core.playback.get_current_tl_track", struct{}{}, tlTrack)
+}
+
+// TimePosition adds a call that stores the playback position in position.
+func (b *Batch) TimePosition(position *int) {
+	b.Call("core.playback.get_time_position", struct{}{}, position)
+}
+
+// TlTracks adds a call that stores the tracklist in tlTracks.
+func (b *Batch) TlTracks(tlTracks *[]TlTrack) {
+	b.Call("core.tracklist.get_tl_tracks", struct{}{}, tlTracks)
+}
+
+// Batch sends all of b's calls to mopidy in one request, storing their
+// results. If any of the calls fail, the first failure is returned.
+func (c *Client) Batch(ctx context.Context, b *Batch) error {
+	if len(b.calls) == 0 {
+		return nil
+	}
+
+	requests := make([]mopidyRequest, len(b.calls))
+	for i, call := range b.calls {
+		requests[i] = c.newRequest(call.method, call.params)
+	}
+
+	body, err := c.post(ctx, requests)
+	if err != nil {
+		return err
+	}
+
+	var responses []modipyResponse
+	if err = json.Unmarshal(body, &responses); err != nil {
+		// The batch as a whole can fail with a single response, in
+		// which case its first call is the first failure.
+		var response modipyResponse
+		if json.Unmarshal(body, &response) == nil && response.Err != nil {
+			return response.Err.typed(b.calls[0].method)
+		}
+
+		return &ProtocolError{Message: err.Error()}
+	}
+
+	// Responses can come back in any order. Errors about calls mopidy
+	// couldn't make sense of have no id, so they're for whichever calls
+	// weren't answered.
+	byID := make(map[int64]*modipyResponse)
+	var unanswered *rpcError
+	for i := range responses {
+		if responses[i].ID != nil {
+			byID[*responses[i].ID] = &responses[i]
+		} else if unanswered == nil {
+			unanswered = responses[i].Err
+		}
+	}
+
+	for i, call := range b.calls {
+		id := requests[i].ID
+
+		response, ok := byID[id]
+		if !ok && unanswered != nil {
+			return unanswered.typed(call.method)
+		} else if !ok {
+			return &ProtocolError{Message: fmt.Sprintf("no response to request %v", id)}
+		}
+
+		if err = response.check(call.method, id); err != nil {
+			return err
+		}
+
+		if err = call.decode(response.Result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type SearchArgs struct {
 	TrackName []string `json:"track_name,omitempty"`
 	Artist    []string `json:"artist,omitempty"`
 	Genre     []string `json:"genre,omitempty"`
+	Album     []string `json:"album,omitempty"`
+	Any       []string `json:"any,omitempty"`
 }
 
 type SearchResult struct {
 	Tracks []Track `json:"tracks"`
 }
 
-func (c *Client) Search(args SearchArgs) (searchResults []SearchResult, err error) {
-	resp, err := c.request("core.library.search", args)
+func (c *Client) Search(ctx context.Context, args SearchArgs) (searchResults []SearchResult, err error) {
+	resp, err := c.request(ctx, "core.library.search", args)
 	if err != nil {
 		return searchResults, err
 	}
 
 	searchResults = make([]SearchResult, 0)
-	if err = json.Unmarshal([]byte(resp.Result), &searchResults); err != nil {
+	if err = decodeResult(resp.Result, &searchResults); err != nil {
 		return searchResults, err
 	}
 
 	return searchResults, nil
 }
 
-func (c *Client) Play() error {
-	_, err := c.request("core.playback.play", struct{}{})
+func (c *Client) Play(ctx context.Context) error {
+	_, err := c.request(ctx, "core.playback.play", struct{}{})
 	return err
 }
 
-func (c *Client) Resume() error {
-	_, err := c.request("core.playback.resume", struct{}{})
+func (c *Client) Resume(ctx context.Context) error {
+	_, err := c.request(ctx, "core.playback.resume", struct{}{})
 	return err
 }
 
-func (c *Client) Pause() error {
-	_, err := c.request("core.playback.pause", struct{}{})
+func (c *Client) Pause(ctx context.Context) error {
+	_, err := c.request(ctx, "core.playback.pause", struct{}{})
 	return err
 }
 
-func (c *Client) Stop() error {
-	_, err := c.request("core.playback.stop", struct{}{})
+func (c *Client) Stop(ctx context.Context) error {
+	_, err := c.request(ctx, "core.playback.stop", struct{}{})
 	return err
 }
 
-func (c *Client) Next() error {
-	_, err := c.request("core.playback.next", struct{}{})
+func (c *Client) Next(ctx context.Context) error {
+	_, err := c.request(ctx, "core.playback.next", struct{}{})
 	return err
 }
 
-func (c *Client) CurrentState() (playState PlayState, err error) {
-	resp, err := c.request("core.playback.get_state", struct{}{})
+func (c *Client) Previous(ctx context.Context) error {
+	_, err := c.request(ctx, "core.playback.previous", struct{}{})
+	return err
+}
+
+func (c *Client) Seek(ctx context.Context, position int) error {
+	params := struct {
+		TimePosition int `json:"time_position"`
+	}{
+		TimePosition: position,
+	}
+
+	resp, err := c.request(ctx, "core.playback.seek", params)
 	if err != nil {
-		return Unknown, err
+		return err
+	}
+
+	var ok bool
+	if err = decodeResult(resp.Result, &ok); err != nil {
+		return err
+	}
+
+	if !ok {
+		return &BackendError{Message: fmt.Sprintf("unable to seek to %v", position)}
 	}
 
-	var state string
-	err = json.Unmarshal(resp.Result, &state)
+	return nil
+}
+
+func (c *Client) GetVolume(ctx context.Context) (volume int, err error) {
+	resp, err := c.request(ctx, "core.mixer.get_volume", struct{}{})
 	if err != nil {
-		return Unknown, err
+		return 0, err
+	}
+
+	// Volume is null if there's no mixer.
+	var v *int
+	if err = decodeResult(resp.Result, &v); err != nil {
+		return 0, err
+	}
+
+	if v == nil {
+		return 0, &NoMixerError{Method: "core.mixer.get_volume"}
+	}
+
+	return *v, nil
+}
+
+func (c *Client) SetVolume(ctx context.Context, volume int) error {
+	params := struct {
+		Volume int `json:"volume"`
+	}{
+		Volume: volume,
+	}
+
+	return c.mixerRequest(ctx, "core.mixer.set_volume", params)
+}
+
+func (c *Client) GetMute(ctx context.Context) (muted bool, err error) {
+	resp, err := c.request(ctx, "core.mixer.get_mute", struct{}{})
+	if err != nil {
+		return false, err
+	}
+
+	// Mute is null if there's no mixer, which is as good as unmuted.
+	var m *bool
+	if err = decodeResult(resp.Result, &m); err != nil {
+		return false, err
 	}
 
-	switch state {
-	case "playing":
-		playState = Playing
-		break
-	case "stopped":
-		playState = Stopped
-		break
-	case "paused":
-		playState = Stopped
-		break
-	default:
-		playState = Unknown
+	return m != nil && *m, nil
+}
+
+func (c *Client) SetMute(ctx context.Context, mute bool) error {
+	params := struct {
+		Mute bool `json:"mute"`
+	}{
+		Mute: mute,
 	}
 
-	return playState, nil
+	return c.mixerRequest(ctx, "core.mixer.set_mute", params)
 }
 
-func (c *Client) CurrentlyPlaying() (track Track, err error) {
-	resp, err := c.request("core.playback.get_current_track", struct{}{})
+// mixerRequest performs a mixer request that reports whether
+// it succeeded.
+func (c *Client) mixerRequest(ctx context.Context, method string, params interface{}) error {
+	resp, err := c.request(ctx, method, params)
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	if err = decodeResult(resp.Result, &ok); err != nil {
+		return err
+	}
+
+	if !ok {
+		return &NoMixerError{Method: method}
+	}
+
+	return nil
+}
+
+func (c *Client) CurrentState(ctx context.Context) (playState PlayState, err error) {
+	resp, err := c.request(ctx, "core.playback.get_state", struct{}{})
+	if err != nil {
+		return Unknown, err
+	}
+
+	err = decodeResult(resp.Result, &playState)
+	return playState, err
+}
+
+func (c *Client) CurrentlyPlaying(ctx context.Context) (track Track, err error) {
+	resp, err := c.request(ctx, "core.playback.get_current_track", struct{}{})
 	if err != nil {
 		return track, err
 	}
 
-	err = json.Unmarshal(resp.Result, &track)
+	err = decodeResult(resp.Result, &track)
 	return track, err
 }
 
-func (c *Client) History() (uris []string, err error) {
-	resp, err := c.request("core.history.get_history", struct{}{})
+func (c *Client) TimePosition(ctx context.Context) (position int, err error) {
+	resp, err := c.request(ctx, "core.playback.get_time_position", struct{}{})
+	if err != nil {
+		return 0, err
+	}
+
+	err = decodeResult(resp.Result, &position)
+	return position, err
+}
+
+// History returns the tracks mopidy has played, most recent first.
+// Entries that can't be decoded are skipped, rather than losing the
+// rest of the history to them. Why each was skipped is returned as
+// a *HistoryEntryError in skipped.
+func (c *Client) History(ctx context.Context) (history []HistoryEntry, skipped []error, err error) {
+	resp, err := c.request(ctx, "core.history.get_history", struct{}{})
 	if err != nil {
-		return uris, err
+		return nil, nil, err
 	}
 
-	// Seriously, mopidy, why can't you play nice. This is so ugly.
-	var resultSet [][]interface{}
-	if err = json.Unmarshal(resp.Result, &resultSet); err != nil {
-		return uris, err
+	var entries []json.RawMessage
+	if err = decodeResult(resp.Result, &entries); err != nil {
+		return nil, nil, err
 	}
 
-	uris = make([]string, 0)
-	for _, r := range resultSet {
-		uris = append(uris, r[1].(map[string]interface{})["uri"].(string))
+	history = make([]HistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		var entry HistoryEntry
+		if err := json.Unmarshal(e, &entry); err != nil {
+			skipped = append(skipped, &HistoryEntryError{Entry: e, Err: err})
+			continue
+		}
+
+		history = append(history, entry)
 	}
 
-	return uris, err
+	return history, skipped, nil
 }
 
-func (c *Client) SetConsume(consume bool) error {
+// HistorySince returns the tracks mopidy has started playing since t,
+// most recent first. Entries are skipped as they are by History.
+func (c *Client) HistorySince(ctx context.Context, t time.Time) (history []HistoryEntry, skipped []error, err error) {
+	history, skipped, err = c.History(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Mopidy's timestamps only have millisecond precision.
+	t = t.Truncate(time.Millisecond)
+
+	for i, entry := range history {
+		if entry.Timestamp.Before(t) {
+			return history[:i], skipped, nil
+		}
+	}
+
+	return history, skipped, nil
+}
+
+func (c *Client) SetConsume(ctx context.Context, consume bool) error {
 	params := struct {
 		Value bool `json:"value"`
 	}{
 		Value: consume,
 	}
 
-	_, err := c.request("core.tracklist.set_consume", params)
+	_, err := c.request(ctx, "core.tracklist.set_consume", params)
 	return err
 }
 
-func (c *Client) AddTracks(tracks []Track) (tracksAdded []Track, err error) {
+// AddTracks appends tracks to the tracklist, returning the entries
+// that were added.
+func (c *Client) AddTracks(ctx context.Context, tracks []Track) (tracksAdded []TlTrack, err error) {
+	return c.InsertTracks(ctx, tracks, -1)
+}
+
+// InsertTracks adds tracks to the tracklist, starting at position. If
+// position is negative, the tracks are appended.
+func (c *Client) InsertTracks(ctx context.Context, tracks []Track, position int) (tracksAdded []TlTrack, err error) {
 	params := struct {
-		URIs []string `json:"uris"`
+		URIs       []string `json:"uris"`
+		AtPosition *int     `json:"at_position,omitempty"`
 	}{URIs: make([]string, len(tracks))}
 
 	for i := range tracks {
 		params.URIs[i] = tracks[i].URI
 	}
 
-	resp, err := c.request("core.tracklist.add", params)
+	if position >= 0 {
+		params.AtPosition = &position
+	}
+
+	resp, err := c.request(ctx, "core.tracklist.add", params)
 	if err != nil {
 		return tracksAdded, err
 	}
 
-	if err = json.Unmarshal(resp.Result, &tracksAdded); err != nil {
-		return tracksAdded, err
+	if err = decodeResult(resp.Result, &tracksAdded); err != nil {
+		return nil, err
+	}
+
+	return tracksAdded, nil
+}
+
+// TlTracks returns the tracklist, in playback order.
+func (c *Client) TlTracks(ctx context.Context) (tlTracks []TlTrack, err error) {
+	resp, err := c.request(ctx, "core.tracklist.get_tl_tracks", struct{}{})
+	if err != nil {
+		return tlTracks, err
+	}
+
+	err = decodeResult(resp.Result, &tlTracks)
+	return tlTracks, err
+}
+
+// CurrentTlTrack returns the tracklist entry that is playing,
+// or nil if nothing is.
+func (c *Client) CurrentTlTrack(ctx context.Context) (tlTrack *TlTrack, err error) {
+	resp, err := c.request(ctx, "core.playback.get_current_tl_track", struct{}{})
+	if err != nil {
+		return nil, err
 	}
 
-	return tracksAdded, err
+	err = decodeResult(resp.Result, &tlTrack)
+	return tlTrack, err
+}
+
+// RemoveTracks removes the tracklist entries with the given
+// tlids, returning the entries that were removed.
+func (c *Client) RemoveTracks(ctx context.Context, tlids []int) (removed []TlTrack, err error) {
+	params := struct {
+		Criteria map[string][]int `json:"criteria"`
+	}{
+		Criteria: map[string][]int{"tlid": tlids},
+	}
+
+	resp, err := c.request(ctx, "core.tracklist.remove", params)
+	if err != nil {
+		return removed, err
+	}
+
+	err = decodeResult(resp.Result, &removed)
+	return removed, err
+}
+
+// MoveTracks moves the tracklist entries in [start, end) so
+// that the first of them ends up at position.
+func (c *Client) MoveTracks(ctx context.Context, start, end, position int) error {
+	params := struct {
+		Start      int `json:"start"`
+		End        int `json:"end"`
+		ToPosition int `json:"to_position"`
+	}{
+		Start:      start,
+		End:        end,
+		ToPosition: position,
+	}
+
+	_, err := c.request(ctx, "core.tracklist.move", params)
+	return err
 }
 
-func (c *Client) ClearTracklist() error {
-	_, err := c.request("core.tracklist.clear", struct{}{})
+func (c *Client) ClearTracklist(ctx context.Context) error {
+	_, err := c.request(ctx, "core.tracklist.clear", struct{}{})
 	return err
 }
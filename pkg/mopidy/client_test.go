@@ -0,0 +1,385 @@
+package mopidy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+type rpcRequest struct {
+	ID     int64  `json:"id"`
+	Method string `json:"method"`
+}
+
+func rpcResponse(id int64, result interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result}
+}
+
+func TestRequestIDs(t *testing.T) {
+	var ids []int64
+	offset := int64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		ids = append(ids, req.ID)
+		json.NewEncoder(w).Encode(rpcResponse(req.ID+offset, 42))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	for i := 0; i < 2; i++ {
+		position, err := c.TimePosition(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 42, position)
+	}
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	// Responses to other requests aren't trusted.
+	offset = 1
+	_, err := c.TimePosition(context.Background())
+	assert.Error(t, err)
+}
+
+func TestBatch(t *testing.T) {
+	var requests int
+	var whole bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		var batch []rpcRequest
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		notFound := map[string]interface{}{"code": -32601, "message": "Method not found"}
+		if whole {
+			json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": nil, "error": notFound})
+			return
+		}
+
+		// Answer out of order.
+		var responses []interface{}
+		for i := len(batch) - 1; i >= 0; i-- {
+			switch batch[i].Method {
+			case "core.playback.get_state":
+				responses = append(responses, rpcResponse(batch[i].ID, "playing"))
+			case "core.playback.get_current_tl_track":
+				responses = append(responses, rpcResponse(batch[i].ID, TlTrack{TLID: 3}))
+			case "core.playback.get_time_position":
+				responses = append(responses, rpcResponse(batch[i].ID, 1500))
+			case "core.unidentified":
+				responses = append(responses, map[string]interface{}{"jsonrpc": "2.0", "id": nil, "error": notFound})
+			default:
+				responses = append(responses, map[string]interface{}{"jsonrpc": "2.0", "id": batch[i].ID, "error": notFound})
+			}
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+
+	var state PlayState
+	var current *TlTrack
+	var position int
+
+	var batch Batch
+	batch.CurrentState(&state)
+	batch.CurrentTlTrack(&current)
+	batch.TimePosition(&position)
+	assert.NoError(t, c.Batch(context.Background(), &batch))
+
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, Playing, state)
+	assert.Equal(t, 3, current.TLID)
+	assert.Equal(t, 1500, position)
+
+	// A failed call fails the batch.
+	batch.Call("core.nonexistent", struct{}{}, nil)
+	err := c.Batch(context.Background(), &batch)
+	assert.Equal(t, &MethodNotFoundError{Method: "core.nonexistent"}, err)
+
+	// Errors without an id are for the call that wasn't answered.
+	batch = Batch{}
+	batch.CurrentState(&state)
+	batch.Call("core.unidentified", struct{}{}, nil)
+	err = c.Batch(context.Background(), &batch)
+	assert.Equal(t, &MethodNotFoundError{Method: "core.unidentified"}, err)
+
+	// An error for the whole batch is a failure of its first call.
+	whole = true
+	err = c.Batch(context.Background(), &batch)
+	assert.Equal(t, &MethodNotFoundError{Method: "core.playback.get_state"}, err)
+
+	// Empty batches aren't sent.
+	assert.NoError(t, c.Batch(context.Background(), &Batch{}))
+	assert.Equal(t, 4, requests)
+}
+
+func TestErrors(t *testing.T) {
+	var rpcErr map[string]interface{}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": rpcErr})
+	}))
+	defer server.Close()
+
+	// Without keep-alives, a request after the server is closed
+	// always fails to connect.
+	c := NewClientWithHTTPClient(server.URL, &http.Client{Transport: &http.Transport{DisableKeepAlives: true}})
+	c.SetRetryPolicy(RetryPolicy{})
+
+	// Application errors have a code of 0, and explain themselves in data.
+	rpcErr = map[string]interface{}{
+		"code":    0,
+		"message": "Application error",
+		"data":    map[string]interface{}{"type": "LookupError", "message": "Spotify is offline"},
+	}
+	_, err := c.TlTracks(context.Background())
+	assert.Equal(t, &BackendError{Message: "Application error", Data: "Spotify is offline"}, err)
+	assert.False(t, IsTemporary(err))
+
+	rpcErr = map[string]interface{}{"code": -32602, "message": "Invalid params"}
+	_, err = c.TlTracks(context.Background())
+	assert.Equal(t, &ProtocolError{Code: -32602, Message: "Invalid params"}, err)
+
+	status = http.StatusServiceUnavailable
+	_, err = c.TlTracks(context.Background())
+	assert.IsType(t, &TransportError{}, err)
+	assert.True(t, IsTemporary(err))
+
+	// Gateway timeouts may have reached mopidy, so they aren't retried.
+	status = http.StatusGatewayTimeout
+	_, err = c.TlTracks(context.Background())
+	assert.IsType(t, &TransportError{}, err)
+	assert.False(t, IsTemporary(err))
+
+	// Nothing is listening once the server is closed.
+	server.Close()
+	_, err = c.TlTracks(context.Background())
+	assert.IsType(t, &TransportError{}, err)
+	assert.True(t, IsTemporary(err))
+
+	// A kept alive connection that broke before the request was
+	// written to it can be retried, but not once it's been written.
+	assert.True(t, transportError(io.EOF, true, false).Temporary())
+	assert.False(t, transportError(io.EOF, true, true).Temporary())
+	assert.False(t, transportError(io.EOF, false, false).Temporary())
+}
+
+func TestRetry(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(rpcResponse(req.ID, 42))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	c.SetRetryPolicy(RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+
+	position, err := c.TimePosition(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 42, position)
+	assert.Equal(t, 3, requests)
+
+	// Errors that aren't temporary aren't retried.
+	var calls int
+	permanent := errors.New("permanent")
+	err = DefaultRetryPolicy.Do(context.Background(), func() error {
+		calls++
+		return permanent
+	})
+	assert.Equal(t, permanent, err)
+	assert.Equal(t, 1, calls)
+
+	// Nor are requests once the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = RetryPolicy{Attempts: 3, Backoff: time.Hour}.Do(ctx, func() error {
+		calls++
+		cancel()
+		return statusError(http.StatusBadGateway)
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCurrentState(t *testing.T) {
+	var state string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "core.playback.get_state", req.Method)
+
+		json.NewEncoder(w).Encode(rpcResponse(req.ID, state))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	for name, expected := range map[string]PlayState{
+		"playing": Playing,
+		"paused":  Paused,
+		"stopped": Stopped,
+		"seeking": Unknown,
+	} {
+		state = name
+		actual, err := c.CurrentState(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual, name)
+	}
+}
+
+func TestPlayStateEvents(t *testing.T) {
+	var e Event
+	err := json.Unmarshal([]byte(`{"event": "playback_state_changed", "old_state": "playing", "new_state": "paused"}`), &e)
+	assert.NoError(t, err)
+	assert.Equal(t, Playing, e.OldState)
+	assert.Equal(t, Paused, e.NewState)
+
+	assert.Equal(t, "paused", Paused.String())
+	assert.Equal(t, "unknown", PlayState(42).String())
+}
+
+func TestHistory(t *testing.T) {
+	var history string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(rpcResponse(req.ID, json.RawMessage(history)))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+
+	history = `[
+		[1500000002000, {"__model__": "Ref", "type": "track", "uri": "local:track:b", "name": "B"}],
+		[1500000001000, {"__model__": "Ref", "type": "track", "uri": "local:track:a", "name": "A"}]
+	]`
+	entries, skipped, err := c.History(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, skipped)
+	assert.Equal(t, []HistoryEntry{
+		{Timestamp: time.Unix(1500000002, 0), TrackRef: Ref{Type: "track", URI: "local:track:b", Name: "B"}},
+		{Timestamp: time.Unix(1500000001, 0), TrackRef: Ref{Type: "track", URI: "local:track:a", Name: "A"}},
+	}, entries)
+
+	entries, _, err = c.HistorySince(context.Background(), time.Unix(1500000001, int64(500*time.Millisecond)))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "local:track:b", entries[0].TrackRef.URI)
+
+	// Malformed entries are skipped, rather than losing the rest of the
+	// history, or panicking. The caller hears why.
+	for _, malformed := range []string{
+		`[1500000001000]`,
+		`["yesterday", {"uri": "local:track:a"}]`,
+		`[1500000001000, "local:track:a"]`,
+		`[1500000001000, {"name": "A"}]`,
+		`{"uri": "local:track:a"}`,
+	} {
+		history = `[[1500000002000, {"uri": "local:track:b"}], ` + malformed + `]`
+		entries, skipped, err = c.History(context.Background())
+		assert.NoError(t, err, malformed)
+		assert.Equal(t, []HistoryEntry{
+			{Timestamp: time.Unix(1500000002, 0), TrackRef: Ref{URI: "local:track:b"}},
+		}, entries, malformed)
+
+		require.Len(t, skipped, 1, malformed)
+		require.IsType(t, &HistoryEntryError{}, skipped[0], malformed)
+		assert.JSONEq(t, malformed, string(skipped[0].(*HistoryEntryError).Entry), malformed)
+	}
+
+	// Skipped entries are passed along by HistorySince.
+	history = `[[1500000002000, {"uri": "local:track:b"}], [1500000001000]]`
+	entries, skipped, err = c.HistorySince(context.Background(), time.Unix(1500000001, 0))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Len(t, skipped, 1)
+
+	// A history that isn't a list of entries is still an error.
+	history = `{"uri": "local:track:a"}`
+	_, _, err = c.History(context.Background())
+	assert.IsType(t, &ProtocolError{}, err)
+}
+
+func TestNoMixer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		// Without a mixer, volumes are null, and changing them fails.
+		var result interface{}
+		if req.Method == "core.mixer.set_volume" {
+			result = false
+		}
+		json.NewEncoder(w).Encode(rpcResponse(req.ID, result))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+
+	_, err := c.GetVolume(context.Background())
+	assert.Equal(t, &NoMixerError{Method: "core.mixer.get_volume"}, err)
+
+	err = c.SetVolume(context.Background(), 50)
+	assert.Equal(t, &NoMixerError{Method: "core.mixer.set_volume"}, err)
+
+	// Without a mixer, nothing is muted.
+	muted, err := c.GetMute(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, muted)
+}
+
+func TestMalformedResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		// Seeking fails, and everything else gets a result of the wrong type.
+		var result interface{} = map[string]string{"malformed": "result"}
+		if req.Method == "core.playback.seek" {
+			result = false
+		}
+		json.NewEncoder(w).Encode(rpcResponse(req.ID, result))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	ctx := context.Background()
+
+	err := c.Seek(ctx, 1000)
+	assert.Equal(t, &BackendError{Message: "unable to seek to 1000"}, err)
+
+	calls := map[string]func() error{
+		"Search":       func() error { _, err := c.Search(ctx, SearchArgs{}); return err },
+		"GetVolume":    func() error { _, err := c.GetVolume(ctx); return err },
+		"SetVolume":    func() error { return c.SetVolume(ctx, 50) },
+		"GetMute":      func() error { _, err := c.GetMute(ctx); return err },
+		"CurrentState": func() error { _, err := c.CurrentState(ctx); return err },
+		"TimePosition": func() error { _, err := c.TimePosition(ctx); return err },
+		"InsertTracks": func() error { _, err := c.InsertTracks(ctx, []Track{{URI: "a"}}, 0); return err },
+		"TlTracks":     func() error { _, err := c.TlTracks(ctx); return err },
+		"RemoveTracks": func() error { _, err := c.RemoveTracks(ctx, []int{1}); return err },
+	}
+	for name, call := range calls {
+		assert.IsType(t, &ProtocolError{}, call(), name)
+	}
+}
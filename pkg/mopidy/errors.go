@@ -0,0 +1,166 @@
+package mopidy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// JSON-RPC error codes. See mopidy.internal.jsonrpc.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// TransportError is returned when a request couldn't be sent to mopidy,
+// or its response couldn't be read.
+type TransportError struct {
+	Err error
+
+	// Whether mopidy never saw the request.
+	unsent bool
+}
+
+func (e *TransportError) Error() string {
+	return "mopidy: " + e.Err.Error()
+}
+
+// Temporary returns whether the request failed before reaching mopidy,
+// in which case it can be retried without being made twice.
+func (e *TransportError) Temporary() bool {
+	return e.unsent
+}
+
+// transportError wraps an error from sending a request. reused is whether
+// the request was sent on a connection that had been kept alive, and
+// written whether the request was written to it.
+func transportError(err error, reused, written bool) *TransportError {
+	if u, ok := err.(*url.Error); ok {
+		err = u.Err
+	}
+
+	// Mopidy may have closed a kept alive connection, say because it
+	// restarted, in which case a request that wasn't written to it
+	// never got there either.
+	op, ok := err.(*net.OpError)
+	return &TransportError{Err: err, unsent: (ok && op.Op == "dial") || (reused && !written)}
+}
+
+// statusError is returned when mopidy, or something in front of it,
+// doesn't answer a request with 200 OK.
+func statusError(status int) *TransportError {
+	return &TransportError{
+		Err: fmt.Errorf("unexpected status: %v", http.StatusText(status)),
+
+		// Proxies answer with these when they can't reach mopidy. A gateway
+		// timeout isn't one of them, since mopidy may have got the request,
+		// and just been slow to answer.
+		unsent: status == http.StatusBadGateway ||
+			status == http.StatusServiceUnavailable,
+	}
+}
+
+// ProtocolError is returned when mopidy rejects a request as invalid,
+// or responds with something other than a response to the request.
+// Either way, it's a bug, and not worth retrying.
+type ProtocolError struct {
+	// The JSON-RPC error code, or 0 if the response was the problem.
+	Code    int
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	if e.Code == 0 {
+		return "mopidy: invalid response: " + e.Message
+	}
+
+	return fmt.Sprintf("mopidy: invalid request: %v (code %v)", e.Message, e.Code)
+}
+
+// MethodNotFoundError is returned when mopidy doesn't have a method,
+// say because it's too old, or an extension isn't installed.
+type MethodNotFoundError struct {
+	Method string
+}
+
+func (e *MethodNotFoundError) Error() string {
+	return fmt.Sprintf("mopidy: method %v not found", e.Method)
+}
+
+// BackendError is returned when mopidy fails to carry out a request,
+// say because a backend couldn't reach its service.
+type BackendError struct {
+	Code    int
+	Message string
+
+	// What went wrong, as described by mopidy, if it said.
+	Data string
+}
+
+func (e *BackendError) Error() string {
+	if e.Data == "" {
+		return "mopidy: " + e.Message
+	}
+
+	return fmt.Sprintf("mopidy: %v: %v", e.Message, e.Data)
+}
+
+// NoMixerError is returned when mopidy can't get or change the volume,
+// because it doesn't have a mixer, or the mixer refused.
+type NoMixerError struct {
+	Method string
+}
+
+func (e *NoMixerError) Error() string {
+	return fmt.Sprintf("mopidy: %v: no mixer available", e.Method)
+}
+
+// HistoryEntryError describes a history entry that couldn't
+// be decoded, and so was skipped.
+type HistoryEntryError struct {
+	Entry json.RawMessage
+	Err   error
+}
+
+func (e *HistoryEntryError) Error() string {
+	return fmt.Sprintf("mopidy: invalid history entry %s: %v", e.Entry, e.Err)
+}
+
+// rpcError is the error member of a JSON-RPC response.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Message string `json:"message"`
+	} `json:"data"`
+}
+
+// typed converts e into the error it represents, given the method of
+// the request that failed.
+func (e *rpcError) typed(method string) error {
+	switch e.Code {
+	case codeMethodNotFound:
+		return &MethodNotFoundError{Method: method}
+	case codeParseError, codeInvalidRequest, codeInvalidParams:
+		message := e.Message
+		if e.Data.Message != "" {
+			message += ": " + e.Data.Message
+		}
+		return &ProtocolError{Code: e.Code, Message: message}
+	default:
+		return &BackendError{Code: e.Code, Message: e.Message, Data: e.Data.Message}
+	}
+}
+
+// IsTemporary returns whether err is a failure that may not happen again,
+// so the request is worth retrying.
+func IsTemporary(err error) bool {
+	t, ok := err.(interface {
+		Temporary() bool
+	})
+	return ok && t.Temporary()
+}
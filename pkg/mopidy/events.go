@@ -0,0 +1,139 @@
+package mopidy
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/net/context"
+)
+
+// Core events we care about. See mopidy.core.CoreListener.
+const (
+	TrackPlaybackStarted = "track_playback_started"
+	TrackPlaybackEnded   = "track_playback_ended"
+	PlaybackStateChanged = "playback_state_changed"
+	TracklistChanged     = "tracklist_changed"
+	VolumeChanged        = "volume_changed"
+	MuteChanged          = "mute_changed"
+)
+
+// Event is a core event broadcast over mopidy's WebSocket API. Which
+// fields are set depends on the type of event.
+type Event struct {
+	Type string `json:"event"`
+
+	// Set for track_playback_started and track_playback_ended.
+	TlTrack *TlTrack `json:"tl_track"`
+
+	// Set for track_playback_ended, in milliseconds.
+	TimePosition int `json:"time_position"`
+
+	// Set for playback_state_changed.
+	OldState PlayState `json:"old_state"`
+	NewState PlayState `json:"new_state"`
+
+	// Set for volume_changed and mute_changed respectively.
+	Volume int  `json:"volume"`
+	Mute   bool `json:"mute"`
+}
+
+// EventStream receives core events from mopidy until it is closed,
+// or the connection drops.
+type EventStream struct {
+	conn   *websocket.Conn
+	events chan Event
+
+	errLock sync.Mutex
+	err     error
+}
+
+// websocketURL derives the WebSocket endpoint from the RPC endpoint,
+// i.e. http://localhost:6680/mopidy/rpc -> ws://localhost:6680/mopidy/ws
+func websocketURL(rpcURL string) (string, error) {
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/rpc") + "/ws"
+	return u.String(), nil
+}
+
+// Subscribe opens an EventStream to mopidy. The context only bounds
+// connecting, not the life of the stream.
+func (c *Client) Subscribe(ctx context.Context) (*EventStream, error) {
+	wsURL, err := websocketURL(c.url)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &EventStream{
+		conn:   conn,
+		events: make(chan Event),
+	}
+
+	go s.read()
+
+	return s, nil
+}
+
+func (s *EventStream) read() {
+	defer close(s.events)
+
+	for {
+		var e Event
+		if err := s.conn.ReadJSON(&e); err != nil {
+			s.errLock.Lock()
+			s.err = err
+			s.errLock.Unlock()
+			return
+		}
+
+		// The socket also carries JSON-RPC responses, which
+		// aren't events.
+		if e.Type == "" {
+			continue
+		}
+
+		s.events <- e
+	}
+}
+
+// Events returns a channel of events, which is closed when
+// the stream is closed.
+func (s *EventStream) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns the reason the stream was closed, if any.
+func (s *EventStream) Err() error {
+	s.errLock.Lock()
+	defer s.errLock.Unlock()
+	return s.err
+}
+
+func (s *EventStream) Close() error {
+	err := s.conn.Close()
+
+	// Unblock the reader if nobody is consuming events.
+	go func() {
+		for range s.events {
+		}
+	}()
+
+	return err
+}
@@ -0,0 +1,98 @@
+package mopidy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestWebsocketURL(t *testing.T) {
+	for _, test := range []struct {
+		rpcURL, wsURL string
+	}{
+		{"http://localhost:6680/mopidy/rpc", "ws://localhost:6680/mopidy/ws"},
+		{"https://example.com/mopidy/rpc", "wss://example.com/mopidy/ws"},
+		{"http://localhost:6680/proxied/mopidy/rpc", "ws://localhost:6680/proxied/mopidy/ws"},
+		{"http://localhost:6680", "ws://localhost:6680/ws"},
+	} {
+		wsURL, err := websocketURL(test.rpcURL)
+		assert.NoError(t, err, test.rpcURL)
+		assert.Equal(t, test.wsURL, wsURL, test.rpcURL)
+	}
+
+	_, err := websocketURL("http://[::1")
+	assert.Error(t, err)
+}
+
+func TestEventStream(t *testing.T) {
+	messages := []string{
+		`{"event": "track_playback_started", "tl_track": {"tlid": 3, "track": {"uri": "local:track:a"}}}`,
+
+		// Responses to requests made over the socket aren't events.
+		`{"jsonrpc": "2.0", "id": 1, "result": null}`,
+
+		`{"event": "track_playback_ended", "tl_track": {"tlid": 3, "track": {"uri": "local:track:a"}}, "time_position": 1500}`,
+		`{"event": "playback_state_changed", "old_state": "playing", "new_state": "paused"}`,
+		`{"event": "tracklist_changed"}`,
+	}
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mopidy/ws" {
+			http.NotFound(w, r)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		// Drop the connection once everything is sent.
+		for _, message := range messages {
+			conn.WriteMessage(websocket.TextMessage, []byte(message))
+		}
+		conn.Close()
+	}))
+	defer server.Close()
+
+	s, err := NewClient(server.URL + "/mopidy/rpc").Subscribe(context.Background())
+	require.NoError(t, err)
+	defer s.Close()
+
+	var events []Event
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case e, ok := <-s.Events():
+			if !ok {
+				done = true
+				break
+			}
+			events = append(events, e)
+		case <-timeout:
+			t.Fatal("event stream wasn't closed")
+		}
+	}
+
+	tlTrack := &TlTrack{TLID: 3, Track: Track{URI: "local:track:a"}}
+	assert.Equal(t, []Event{
+		{Type: TrackPlaybackStarted, TlTrack: tlTrack},
+		{Type: TrackPlaybackEnded, TlTrack: tlTrack, TimePosition: 1500},
+		{Type: PlaybackStateChanged, OldState: Playing, NewState: Paused},
+		{Type: TracklistChanged},
+	}, events)
+
+	// The stream says why it closed.
+	assert.Error(t, s.Err())
+
+	// Subscribing fails if there's nothing to subscribe to.
+	_, err = NewClient(server.URL + "/elsewhere/rpc").Subscribe(context.Background())
+	assert.Error(t, err)
+}
@@ -1,5 +1,11 @@
 package mopidy
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type Track struct {
 	Name   string
 	URI    string
@@ -12,3 +18,55 @@ type Artist struct {
 	Name string
 	URI  string
 }
+
+// TlTrack is a track in the tracklist, identified by its
+// tracklist id (tlid).
+type TlTrack struct {
+	TLID  int
+	Track Track
+}
+
+// Ref refers to a track, album, artist, etc. by its URI.
+type Ref struct {
+	Type string
+	URI  string
+	Name string
+}
+
+// HistoryEntry is a track that mopidy played, and when it started.
+type HistoryEntry struct {
+	Timestamp time.Time
+	TrackRef  Ref
+}
+
+// UnmarshalJSON decodes an entry of mopidy's history, which is
+// a [timestamp, ref] pair, with the timestamp in milliseconds
+// since the unix epoch.
+func (h *HistoryEntry) UnmarshalJSON(b []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+
+	if len(pair) != 2 {
+		return fmt.Errorf("history entry has %v elements, not 2", len(pair))
+	}
+
+	var ms int64
+	if err := json.Unmarshal(pair[0], &ms); err != nil {
+		return fmt.Errorf("history entry has an invalid timestamp: %v", err)
+	}
+
+	var ref Ref
+	if err := json.Unmarshal(pair[1], &ref); err != nil {
+		return fmt.Errorf("history entry has an invalid ref: %v", err)
+	}
+
+	if ref.URI == "" {
+		return fmt.Errorf("history entry has no uri")
+	}
+
+	h.Timestamp = time.Unix(0, ms*int64(time.Millisecond))
+	h.TrackRef = ref
+	return nil
+}
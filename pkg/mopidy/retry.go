@@ -0,0 +1,48 @@
+package mopidy
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// RetryPolicy configures how requests that fail temporarily are retried.
+type RetryPolicy struct {
+	// Total number of attempts made. If 1 or less, requests aren't retried.
+	Attempts int
+
+	// Time to wait before the first retry, which doubles with each
+	// subsequent retry, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   3,
+	Backoff:    100 * time.Millisecond,
+	MaxBackoff: 2 * time.Second,
+}
+
+// Do calls fn until it succeeds, fails with an error that isn't
+// temporary, or has been called p.Attempts times, returning its
+// last error. Waiting between attempts is cut short if ctx is done.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Attempts || !IsTemporary(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
@@ -10,14 +10,59 @@ It is generated from these files:
 
 It has these top-level messages:
 	Song
+	Track
 	QueueSongRequest
+	Handshake
+	Lease
+	Session
 	QueueSongResponse
 	SkipSongRequest
 	SkipSongResponse
+	PauseRequest
+	PauseResponse
+	ResumeRequest
+	ResumeResponse
+	StopRequest
+	StopResponse
+	SeekRequest
+	SeekResponse
+	PreviousRequest
+	PreviousResponse
+	QueueEntry
+	ListQueueRequest
+	ListQueueResponse
+	RemoveFromQueueRequest
+	RemoveFromQueueResponse
+	MoveInQueueRequest
+	MoveInQueueResponse
+	ClearQueueRequest
+	ClearQueueResponse
+	GetVolumeRequest
+	GetVolumeResponse
+	SetVolumeRequest
+	SetVolumeResponse
+	SetMuteRequest
+	SetMuteResponse
+	VoteVolumeRequest
+	VoteVolumeResponse
+	PlaybackSettings
+	GetPlaybackSettingsRequest
+	GetPlaybackSettingsResponse
+	SetPlaybackSettingsRequest
+	SetPlaybackSettingsResponse
 	GetPlayingRequest
 	GetPlayingResponse
 	GetPlayHistoryRequest
 	GetPlayHistoryResponse
+	SearchRequest
+	SearchResponse
+	WatchPlaybackRequest
+	PlaybackEvent
+	Faults
+	GetFaultsRequest
+	GetFaultsResponse
+	SetFaultsRequest
+	SetFaultsResponse
 */
 package playsource
 
@@ -39,6 +84,182 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 const _ = proto.ProtoPackageIsVersion1
 
+// PlayState is the state of the playback system.
+type PlayState int32
+
+const (
+	PlayState_UNKNOWN PlayState = 0
+	PlayState_PLAYING PlayState = 1
+	PlayState_PAUSED  PlayState = 2
+	PlayState_STOPPED PlayState = 3
+)
+
+var PlayState_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "PLAYING",
+	2: "PAUSED",
+	3: "STOPPED",
+}
+var PlayState_value = map[string]int32{
+	"UNKNOWN": 0,
+	"PLAYING": 1,
+	"PAUSED":  2,
+	"STOPPED": 3,
+}
+
+func (x PlayState) String() string {
+	return proto.EnumName(PlayState_name, int32(x))
+}
+func (PlayState) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+type QueueSongRequest_Insertion int32
+
+const (
+	QueueSongRequest_APPEND QueueSongRequest_Insertion = 0
+	// Play the song after the one that's playing.
+	QueueSongRequest_PLAY_NEXT   QueueSongRequest_Insertion = 1
+	QueueSongRequest_AT_POSITION QueueSongRequest_Insertion = 2
+)
+
+var QueueSongRequest_Insertion_name = map[int32]string{
+	0: "APPEND",
+	1: "PLAY_NEXT",
+	2: "AT_POSITION",
+}
+var QueueSongRequest_Insertion_value = map[string]int32{
+	"APPEND":      0,
+	"PLAY_NEXT":   1,
+	"AT_POSITION": 2,
+}
+
+func (x QueueSongRequest_Insertion) String() string {
+	return proto.EnumName(QueueSongRequest_Insertion_name, int32(x))
+}
+func (QueueSongRequest_Insertion) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{2, 0}
+}
+
+type QueueSongResponse_Reason int32
+
+const (
+	QueueSongResponse_NONE QueueSongResponse_Reason = 0
+	// The search returned no candidates.
+	QueueSongResponse_NOT_FOUND QueueSongResponse_Reason = 1
+	// There were candidates, but none matched the song closely enough.
+	QueueSongResponse_LOW_CONFIDENCE QueueSongResponse_Reason = 2
+	// The internal queue was full.
+	QueueSongResponse_QUEUE_FULL QueueSongResponse_Reason = 3
+	// The playback system returned an error.
+	QueueSongResponse_BACKEND_ERROR QueueSongResponse_Reason = 4
+	// The playback system refused to add the track.
+	QueueSongResponse_ADD_REJECTED QueueSongResponse_Reason = 5
+)
+
+var QueueSongResponse_Reason_name = map[int32]string{
+	0: "NONE",
+	1: "NOT_FOUND",
+	2: "LOW_CONFIDENCE",
+	3: "QUEUE_FULL",
+	4: "BACKEND_ERROR",
+	5: "ADD_REJECTED",
+}
+var QueueSongResponse_Reason_value = map[string]int32{
+	"NONE":           0,
+	"NOT_FOUND":      1,
+	"LOW_CONFIDENCE": 2,
+	"QUEUE_FULL":     3,
+	"BACKEND_ERROR":  4,
+	"ADD_REJECTED":   5,
+}
+
+func (x QueueSongResponse_Reason) String() string {
+	return proto.EnumName(QueueSongResponse_Reason_name, int32(x))
+}
+func (QueueSongResponse_Reason) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{6, 0} }
+
+type VoteVolumeRequest_Direction int32
+
+const (
+	VoteVolumeRequest_NONE VoteVolumeRequest_Direction = 0
+	VoteVolumeRequest_UP   VoteVolumeRequest_Direction = 1
+	VoteVolumeRequest_DOWN VoteVolumeRequest_Direction = 2
+)
+
+var VoteVolumeRequest_Direction_name = map[int32]string{
+	0: "NONE",
+	1: "UP",
+	2: "DOWN",
+}
+var VoteVolumeRequest_Direction_value = map[string]int32{
+	"NONE": 0,
+	"UP":   1,
+	"DOWN": 2,
+}
+
+func (x VoteVolumeRequest_Direction) String() string {
+	return proto.EnumName(VoteVolumeRequest_Direction_name, int32(x))
+}
+func (VoteVolumeRequest_Direction) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{34, 0}
+}
+
+type PlaybackEvent_Type int32
+
+const (
+	PlaybackEvent_UNKNOWN        PlaybackEvent_Type = 0
+	PlaybackEvent_TRACK_STARTED  PlaybackEvent_Type = 1
+	PlaybackEvent_TRACK_FINISHED PlaybackEvent_Type = 2
+	// A track was skipped. It's followed by TRACK_FINISHED
+	// once the track stops playing.
+	PlaybackEvent_SKIPPED PlaybackEvent_Type = 3
+	PlaybackEvent_PAUSED  PlaybackEvent_Type = 4
+	PlaybackEvent_RESUMED PlaybackEvent_Type = 5
+	// The volume, or whether it's muted, changed, whether through
+	// the playsource or otherwise.
+	PlaybackEvent_VOLUME_CHANGED PlaybackEvent_Type = 6
+	// Tracks were added to, removed from, or moved in the queue.
+	PlaybackEvent_QUEUE_CHANGED PlaybackEvent_Type = 7
+	// The playsource lost its connection to the playback system.
+	// Events may be missed until it reconnects.
+	PlaybackEvent_BACKEND_DISCONNECTED PlaybackEvent_Type = 8
+	// A track couldn't be played. See QueueSongResponse.failed.
+	PlaybackEvent_TRACK_FAILED PlaybackEvent_Type = 9
+	// Playback stopped, say because the queue ran out.
+	PlaybackEvent_STOPPED PlaybackEvent_Type = 10
+)
+
+var PlaybackEvent_Type_name = map[int32]string{
+	0:  "UNKNOWN",
+	1:  "TRACK_STARTED",
+	2:  "TRACK_FINISHED",
+	3:  "SKIPPED",
+	4:  "PAUSED",
+	5:  "RESUMED",
+	6:  "VOLUME_CHANGED",
+	7:  "QUEUE_CHANGED",
+	8:  "BACKEND_DISCONNECTED",
+	9:  "TRACK_FAILED",
+	10: "STOPPED",
+}
+var PlaybackEvent_Type_value = map[string]int32{
+	"UNKNOWN":              0,
+	"TRACK_STARTED":        1,
+	"TRACK_FINISHED":       2,
+	"SKIPPED":              3,
+	"PAUSED":               4,
+	"RESUMED":              5,
+	"VOLUME_CHANGED":       6,
+	"QUEUE_CHANGED":        7,
+	"BACKEND_DISCONNECTED": 8,
+	"TRACK_FAILED":         9,
+	"STOPPED":              10,
+}
+
+func (x PlaybackEvent_Type) String() string {
+	return proto.EnumName(PlaybackEvent_Type_name, int32(x))
+}
+func (PlaybackEvent_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{48, 0} }
+
 type Song struct {
 	// Crowdsound song id.
 	SongId int32 `protobuf:"varint,1,opt,name=song_id" json:"song_id,omitempty"`
@@ -53,14 +274,38 @@ func (m *Song) String() string            { return proto.CompactTextString(m) }
 func (*Song) ProtoMessage()               {}
 func (*Song) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+// Track is a track in the playback system.
+type Track struct {
+	Uri     string   `protobuf:"bytes,1,opt,name=uri" json:"uri,omitempty"`
+	Name    string   `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Artists []string `protobuf:"bytes,3,rep,name=artists" json:"artists,omitempty"`
+	// Length of the track, in milliseconds.
+	LengthMs int32 `protobuf:"varint,4,opt,name=length_ms" json:"length_ms,omitempty"`
+}
+
+func (m *Track) Reset()                    { *m = Track{} }
+func (m *Track) String() string            { return proto.CompactTextString(m) }
+func (*Track) ProtoMessage()               {}
+func (*Track) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
 type QueueSongRequest struct {
 	Song *Song `protobuf:"bytes,1,opt,name=song" json:"song,omitempty"`
+	// Identifies the controller. Only valid on the first request of a stream.
+	// If omitted, the stream tries to become primary anonymously, and the
+	// server does not respond with its lease.
+	Handshake *Handshake `protobuf:"bytes,2,opt,name=handshake" json:"handshake,omitempty"`
+	// Where to queue the song.
+	Insertion QueueSongRequest_Insertion `protobuf:"varint,3,opt,name=insertion,enum=Playsource.QueueSongRequest_Insertion" json:"insertion,omitempty"`
+	// Position to queue the song at, for AT_POSITION, counting from 0 like
+	// ListQueue. Positions before the playing song are treated as PLAY_NEXT,
+	// and positions past the end of the queue as APPEND.
+	Position int32 `protobuf:"varint,4,opt,name=position" json:"position,omitempty"`
 }
 
 func (m *QueueSongRequest) Reset()                    { *m = QueueSongRequest{} }
 func (m *QueueSongRequest) String() string            { return proto.CompactTextString(m) }
 func (*QueueSongRequest) ProtoMessage()               {}
-func (*QueueSongRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*QueueSongRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
 
 func (m *QueueSongRequest) GetSong() *Song {
 	if m != nil {
@@ -69,39 +314,508 @@ func (m *QueueSongRequest) GetSong() *Song {
 	return nil
 }
 
+func (m *QueueSongRequest) GetHandshake() *Handshake {
+	if m != nil {
+		return m.Handshake
+	}
+	return nil
+}
+
+type Handshake struct {
+	// Name of the controller, i.e. the Crowdsound instance.
+	Controller string `protobuf:"bytes,1,opt,name=controller" json:"controller,omitempty"`
+	// Take the lease from the current primary, if there is one.
+	Takeover bool `protobuf:"varint,2,opt,name=takeover" json:"takeover,omitempty"`
+	// Open the stream as a read-only observer.
+	Observe bool `protobuf:"varint,3,opt,name=observe" json:"observe,omitempty"`
+	// Resume the session with this id, rather than starting a new one.
+	// If the session no longer exists, a new one is started.
+	SessionId string `protobuf:"bytes,4,opt,name=session_id" json:"session_id,omitempty"`
+}
+
+func (m *Handshake) Reset()                    { *m = Handshake{} }
+func (m *Handshake) String() string            { return proto.CompactTextString(m) }
+func (*Handshake) ProtoMessage()               {}
+func (*Handshake) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+// Lease describes the primary controller's lease.
+type Lease struct {
+	// Name of the primary.
+	Holder string `protobuf:"bytes,1,opt,name=holder" json:"holder,omitempty"`
+	// Fencing token of the lease. Increases every time the lease changes hands.
+	// Only sent to the primary.
+	Token uint64 `protobuf:"varint,2,opt,name=token" json:"token,omitempty"`
+}
+
+func (m *Lease) Reset()                    { *m = Lease{} }
+func (m *Lease) String() string            { return proto.CompactTextString(m) }
+func (*Lease) ProtoMessage()               {}
+func (*Lease) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+// Session describes the primary's QueueSong session.
+type Session struct {
+	// Pass in a handshake to resume the session after reconnecting.
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// Whether the requested session was resumed.
+	Resumed bool `protobuf:"varint,2,opt,name=resumed" json:"resumed,omitempty"`
+	// Songs that are queued in the session, and haven't been reported finished.
+	// This includes songs that finished while the primary was disconnected,
+	// which are reported on the new stream. Songs sent on the previous stream
+	// that aren't included were never queued, and should be sent again.
+	InFlight []*Song `protobuf:"bytes,3,rep,name=in_flight" json:"in_flight,omitempty"`
+}
+
+func (m *Session) Reset()                    { *m = Session{} }
+func (m *Session) String() string            { return proto.CompactTextString(m) }
+func (*Session) ProtoMessage()               {}
+func (*Session) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *Session) GetInFlight() []*Song {
+	if m != nil {
+		return m.InFlight
+	}
+	return nil
+}
+
 type QueueSongResponse struct {
 	// The Crowdsound song in question.
 	SongId int32 `protobuf:"varint,1,opt,name=song_id" json:"song_id,omitempty"`
 	// Whether or not the song was queued. If queued == false, then
 	// the song may not have been found (found == false), or the internal
-	// queue was full (found == true).
+	// queue was full (found == true). See reason for details.
 	Queued bool `protobuf:"varint,2,opt,name=queued" json:"queued,omitempty"`
 	// Whether or not a song was found. Will always be true if queued == true.
 	Found bool `protobuf:"varint,3,opt,name=found" json:"found,omitempty"`
 	// Whether or not the song was finished.
 	Finished bool `protobuf:"varint,4,opt,name=finished" json:"finished,omitempty"`
+	// The track the song resolved to. Set whenever a candidate track
+	// was found, even if it wasn't queued.
+	Track *Track `protobuf:"bytes,5,opt,name=track" json:"track,omitempty"`
+	// Confidence, from 0 to 1, that the track is the requested song.
+	Confidence float64 `protobuf:"fixed64,6,opt,name=confidence" json:"confidence,omitempty"`
+	// Why the song wasn't queued, if queued == false.
+	Reason QueueSongResponse_Reason `protobuf:"varint,7,opt,name=reason,enum=Playsource.QueueSongResponse_Reason" json:"reason,omitempty"`
+	// Sent in response to a handshake, before anything else.
+	Lease   *Lease   `protobuf:"bytes,8,opt,name=lease" json:"lease,omitempty"`
+	Session *Session `protobuf:"bytes,9,opt,name=session" json:"session,omitempty"`
+	// Whether the song couldn't be played, say because the playback system
+	// couldn't stream it, it ended well short of its length, or it was removed
+	// by another client of the playback system. Failed songs are also finished,
+	// since they won't be reported again.
+	Failed bool `protobuf:"varint,10,opt,name=failed" json:"failed,omitempty"`
+	// Why the song failed, if failed == true.
+	Error string `protobuf:"bytes,11,opt,name=error" json:"error,omitempty"`
+	// Whether the song was skipped. Skipped songs are reported instead of
+	// finished, so finished is false, but they won't be reported again.
+	Skipped bool `protobuf:"varint,12,opt,name=skipped" json:"skipped,omitempty"`
 }
 
 func (m *QueueSongResponse) Reset()                    { *m = QueueSongResponse{} }
 func (m *QueueSongResponse) String() string            { return proto.CompactTextString(m) }
 func (*QueueSongResponse) ProtoMessage()               {}
-func (*QueueSongResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*QueueSongResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *QueueSongResponse) GetTrack() *Track {
+	if m != nil {
+		return m.Track
+	}
+	return nil
+}
+
+func (m *QueueSongResponse) GetLease() *Lease {
+	if m != nil {
+		return m.Lease
+	}
+	return nil
+}
+
+func (m *QueueSongResponse) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
 
 type SkipSongRequest struct {
+	// Why the song is being skipped, for the record.
+	Reason string `protobuf:"bytes,1,opt,name=reason" json:"reason,omitempty"`
+	// Who's asking for the skip. Required when skips are voted on, since
+	// each requester only gets one vote per song.
+	Requester string `protobuf:"bytes,2,opt,name=requester" json:"requester,omitempty"`
+	// If set, the skip only applies while this song is playing, so a skip
+	// that arrives late doesn't skip the song after it. Only song_id is
+	// compared.
+	Expected *Song `protobuf:"bytes,3,opt,name=expected" json:"expected,omitempty"`
 }
 
 func (m *SkipSongRequest) Reset()                    { *m = SkipSongRequest{} }
 func (m *SkipSongRequest) String() string            { return proto.CompactTextString(m) }
 func (*SkipSongRequest) ProtoMessage()               {}
-func (*SkipSongRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+func (*SkipSongRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *SkipSongRequest) GetExpected() *Song {
+	if m != nil {
+		return m.Expected
+	}
+	return nil
+}
 
 type SkipSongResponse struct {
+	// Whether the song was skipped. If skips are voted on, it isn't until
+	// votes reaches threshold.
+	Skipped bool `protobuf:"varint,1,opt,name=skipped" json:"skipped,omitempty"`
+	// Current votes to skip the playing song, and the votes needed to skip
+	// it. Both are 0 if skips aren't voted on.
+	Votes     int32 `protobuf:"varint,2,opt,name=votes" json:"votes,omitempty"`
+	Threshold int32 `protobuf:"varint,3,opt,name=threshold" json:"threshold,omitempty"`
 }
 
 func (m *SkipSongResponse) Reset()                    { *m = SkipSongResponse{} }
 func (m *SkipSongResponse) String() string            { return proto.CompactTextString(m) }
 func (*SkipSongResponse) ProtoMessage()               {}
-func (*SkipSongResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*SkipSongResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+type PauseRequest struct {
+}
+
+func (m *PauseRequest) Reset()                    { *m = PauseRequest{} }
+func (m *PauseRequest) String() string            { return proto.CompactTextString(m) }
+func (*PauseRequest) ProtoMessage()               {}
+func (*PauseRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+type PauseResponse struct {
+}
+
+func (m *PauseResponse) Reset()                    { *m = PauseResponse{} }
+func (m *PauseResponse) String() string            { return proto.CompactTextString(m) }
+func (*PauseResponse) ProtoMessage()               {}
+func (*PauseResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+type ResumeRequest struct {
+}
+
+func (m *ResumeRequest) Reset()                    { *m = ResumeRequest{} }
+func (m *ResumeRequest) String() string            { return proto.CompactTextString(m) }
+func (*ResumeRequest) ProtoMessage()               {}
+func (*ResumeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+type ResumeResponse struct {
+}
+
+func (m *ResumeResponse) Reset()                    { *m = ResumeResponse{} }
+func (m *ResumeResponse) String() string            { return proto.CompactTextString(m) }
+func (*ResumeResponse) ProtoMessage()               {}
+func (*ResumeResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+type StopRequest struct {
+}
+
+func (m *StopRequest) Reset()                    { *m = StopRequest{} }
+func (m *StopRequest) String() string            { return proto.CompactTextString(m) }
+func (*StopRequest) ProtoMessage()               {}
+func (*StopRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+type StopResponse struct {
+}
+
+func (m *StopResponse) Reset()                    { *m = StopResponse{} }
+func (m *StopResponse) String() string            { return proto.CompactTextString(m) }
+func (*StopResponse) ProtoMessage()               {}
+func (*StopResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+type SeekRequest struct {
+	// Position to seek to, in milliseconds.
+	PositionMs int32 `protobuf:"varint,1,opt,name=position_ms" json:"position_ms,omitempty"`
+}
+
+func (m *SeekRequest) Reset()                    { *m = SeekRequest{} }
+func (m *SeekRequest) String() string            { return proto.CompactTextString(m) }
+func (*SeekRequest) ProtoMessage()               {}
+func (*SeekRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+type SeekResponse struct {
+}
+
+func (m *SeekResponse) Reset()                    { *m = SeekResponse{} }
+func (m *SeekResponse) String() string            { return proto.CompactTextString(m) }
+func (*SeekResponse) ProtoMessage()               {}
+func (*SeekResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+type PreviousRequest struct {
+}
+
+func (m *PreviousRequest) Reset()                    { *m = PreviousRequest{} }
+func (m *PreviousRequest) String() string            { return proto.CompactTextString(m) }
+func (*PreviousRequest) ProtoMessage()               {}
+func (*PreviousRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+type PreviousResponse struct {
+}
+
+func (m *PreviousResponse) Reset()                    { *m = PreviousResponse{} }
+func (m *PreviousResponse) String() string            { return proto.CompactTextString(m) }
+func (*PreviousResponse) ProtoMessage()               {}
+func (*PreviousResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+// QueueEntry is a track in the playback system's queue.
+type QueueEntry struct {
+	// The song the track was queued for. Only set if it
+	// was queued through the playsource.
+	Song  *Song  `protobuf:"bytes,1,opt,name=song" json:"song,omitempty"`
+	Track *Track `protobuf:"bytes,2,opt,name=track" json:"track,omitempty"`
+	// Whether the track is playing (or paused).
+	Playing bool `protobuf:"varint,3,opt,name=playing" json:"playing,omitempty"`
+}
+
+func (m *QueueEntry) Reset()                    { *m = QueueEntry{} }
+func (m *QueueEntry) String() string            { return proto.CompactTextString(m) }
+func (*QueueEntry) ProtoMessage()               {}
+func (*QueueEntry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *QueueEntry) GetSong() *Song {
+	if m != nil {
+		return m.Song
+	}
+	return nil
+}
+
+func (m *QueueEntry) GetTrack() *Track {
+	if m != nil {
+		return m.Track
+	}
+	return nil
+}
+
+type ListQueueRequest struct {
+}
+
+func (m *ListQueueRequest) Reset()                    { *m = ListQueueRequest{} }
+func (m *ListQueueRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListQueueRequest) ProtoMessage()               {}
+func (*ListQueueRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+type ListQueueResponse struct {
+	Entries []*QueueEntry `protobuf:"bytes,1,rep,name=entries" json:"entries,omitempty"`
+}
+
+func (m *ListQueueResponse) Reset()                    { *m = ListQueueResponse{} }
+func (m *ListQueueResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListQueueResponse) ProtoMessage()               {}
+func (*ListQueueResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *ListQueueResponse) GetEntries() []*QueueEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+type RemoveFromQueueRequest struct {
+	SongId int32 `protobuf:"varint,1,opt,name=song_id" json:"song_id,omitempty"`
+	// Fencing token of the primary's lease.
+	LeaseToken uint64 `protobuf:"varint,2,opt,name=lease_token" json:"lease_token,omitempty"`
+}
+
+func (m *RemoveFromQueueRequest) Reset()                    { *m = RemoveFromQueueRequest{} }
+func (m *RemoveFromQueueRequest) String() string            { return proto.CompactTextString(m) }
+func (*RemoveFromQueueRequest) ProtoMessage()               {}
+func (*RemoveFromQueueRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+type RemoveFromQueueResponse struct {
+}
+
+func (m *RemoveFromQueueResponse) Reset()                    { *m = RemoveFromQueueResponse{} }
+func (m *RemoveFromQueueResponse) String() string            { return proto.CompactTextString(m) }
+func (*RemoveFromQueueResponse) ProtoMessage()               {}
+func (*RemoveFromQueueResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+type MoveInQueueRequest struct {
+	SongId int32 `protobuf:"varint,1,opt,name=song_id" json:"song_id,omitempty"`
+	// Position to move the song to, counting from 0.
+	Position int32 `protobuf:"varint,2,opt,name=position" json:"position,omitempty"`
+	// Fencing token of the primary's lease.
+	LeaseToken uint64 `protobuf:"varint,3,opt,name=lease_token" json:"lease_token,omitempty"`
+}
+
+func (m *MoveInQueueRequest) Reset()                    { *m = MoveInQueueRequest{} }
+func (m *MoveInQueueRequest) String() string            { return proto.CompactTextString(m) }
+func (*MoveInQueueRequest) ProtoMessage()               {}
+func (*MoveInQueueRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+type MoveInQueueResponse struct {
+}
+
+func (m *MoveInQueueResponse) Reset()                    { *m = MoveInQueueResponse{} }
+func (m *MoveInQueueResponse) String() string            { return proto.CompactTextString(m) }
+func (*MoveInQueueResponse) ProtoMessage()               {}
+func (*MoveInQueueResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+type ClearQueueRequest struct {
+	// Fencing token of the primary's lease.
+	LeaseToken uint64 `protobuf:"varint,1,opt,name=lease_token" json:"lease_token,omitempty"`
+}
+
+func (m *ClearQueueRequest) Reset()                    { *m = ClearQueueRequest{} }
+func (m *ClearQueueRequest) String() string            { return proto.CompactTextString(m) }
+func (*ClearQueueRequest) ProtoMessage()               {}
+func (*ClearQueueRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+type ClearQueueResponse struct {
+	// Number of tracks that were removed.
+	Removed int32 `protobuf:"varint,1,opt,name=removed" json:"removed,omitempty"`
+}
+
+func (m *ClearQueueResponse) Reset()                    { *m = ClearQueueResponse{} }
+func (m *ClearQueueResponse) String() string            { return proto.CompactTextString(m) }
+func (*ClearQueueResponse) ProtoMessage()               {}
+func (*ClearQueueResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+type GetVolumeRequest struct {
+}
+
+func (m *GetVolumeRequest) Reset()                    { *m = GetVolumeRequest{} }
+func (m *GetVolumeRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetVolumeRequest) ProtoMessage()               {}
+func (*GetVolumeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+type GetVolumeResponse struct {
+	// Volume, from 0 to 100.
+	Volume int32 `protobuf:"varint,1,opt,name=volume" json:"volume,omitempty"`
+	Muted  bool  `protobuf:"varint,2,opt,name=muted" json:"muted,omitempty"`
+}
+
+func (m *GetVolumeResponse) Reset()                    { *m = GetVolumeResponse{} }
+func (m *GetVolumeResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetVolumeResponse) ProtoMessage()               {}
+func (*GetVolumeResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+type SetVolumeRequest struct {
+	// Volume, from 0 to 100.
+	Volume int32 `protobuf:"varint,1,opt,name=volume" json:"volume,omitempty"`
+}
+
+func (m *SetVolumeRequest) Reset()                    { *m = SetVolumeRequest{} }
+func (m *SetVolumeRequest) String() string            { return proto.CompactTextString(m) }
+func (*SetVolumeRequest) ProtoMessage()               {}
+func (*SetVolumeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+type SetVolumeResponse struct {
+}
+
+func (m *SetVolumeResponse) Reset()                    { *m = SetVolumeResponse{} }
+func (m *SetVolumeResponse) String() string            { return proto.CompactTextString(m) }
+func (*SetVolumeResponse) ProtoMessage()               {}
+func (*SetVolumeResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+type SetMuteRequest struct {
+	Muted bool `protobuf:"varint,1,opt,name=muted" json:"muted,omitempty"`
+}
+
+func (m *SetMuteRequest) Reset()                    { *m = SetMuteRequest{} }
+func (m *SetMuteRequest) String() string            { return proto.CompactTextString(m) }
+func (*SetMuteRequest) ProtoMessage()               {}
+func (*SetMuteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+type SetMuteResponse struct {
+}
+
+func (m *SetMuteResponse) Reset()                    { *m = SetMuteResponse{} }
+func (m *SetMuteResponse) String() string            { return proto.CompactTextString(m) }
+func (*SetMuteResponse) ProtoMessage()               {}
+func (*SetMuteResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+type VoteVolumeRequest struct {
+	// Identifies the listener. Only a listener's latest vote counts.
+	Voter     string                      `protobuf:"bytes,1,opt,name=voter" json:"voter,omitempty"`
+	Direction VoteVolumeRequest_Direction `protobuf:"varint,2,opt,name=direction,enum=Playsource.VoteVolumeRequest_Direction" json:"direction,omitempty"`
+}
+
+func (m *VoteVolumeRequest) Reset()                    { *m = VoteVolumeRequest{} }
+func (m *VoteVolumeRequest) String() string            { return proto.CompactTextString(m) }
+func (*VoteVolumeRequest) ProtoMessage()               {}
+func (*VoteVolumeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+type VoteVolumeResponse struct {
+	// Volume after the vote, from 0 to 100.
+	Volume int32 `protobuf:"varint,1,opt,name=volume" json:"volume,omitempty"`
+	// Whether the vote changed the volume.
+	Changed bool `protobuf:"varint,2,opt,name=changed" json:"changed,omitempty"`
+	// Net votes (up minus down) that are currently cast, and how
+	// many are needed to change the volume in either direction.
+	Votes     int32 `protobuf:"varint,3,opt,name=votes" json:"votes,omitempty"`
+	Threshold int32 `protobuf:"varint,4,opt,name=threshold" json:"threshold,omitempty"`
+}
+
+func (m *VoteVolumeResponse) Reset()                    { *m = VoteVolumeResponse{} }
+func (m *VoteVolumeResponse) String() string            { return proto.CompactTextString(m) }
+func (*VoteVolumeResponse) ProtoMessage()               {}
+func (*VoteVolumeResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+type PlaybackSettings struct {
+	// Duration to fade between songs over, in milliseconds. Mopidy can't
+	// overlap songs, so the end of each song is faded out, and the start of
+	// the next is faded in. No fading is done if 0.
+	CrossfadeMs int32 `protobuf:"varint,1,opt,name=crossfade_ms" json:"crossfade_ms,omitempty"`
+	// Whether songs play back to back, without silence between them. Mopidy
+	// always plays gaplessly, so this can't be disabled for it.
+	Gapless bool `protobuf:"varint,2,opt,name=gapless" json:"gapless,omitempty"`
+	// Duration to fade skipped songs out over, in milliseconds. Skipped songs
+	// stop immediately if 0.
+	SkipFadeMs int32 `protobuf:"varint,3,opt,name=skip_fade_ms" json:"skip_fade_ms,omitempty"`
+}
+
+func (m *PlaybackSettings) Reset()                    { *m = PlaybackSettings{} }
+func (m *PlaybackSettings) String() string            { return proto.CompactTextString(m) }
+func (*PlaybackSettings) ProtoMessage()               {}
+func (*PlaybackSettings) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+type GetPlaybackSettingsRequest struct {
+}
+
+func (m *GetPlaybackSettingsRequest) Reset()                    { *m = GetPlaybackSettingsRequest{} }
+func (m *GetPlaybackSettingsRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetPlaybackSettingsRequest) ProtoMessage()               {}
+func (*GetPlaybackSettingsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+type GetPlaybackSettingsResponse struct {
+	Settings *PlaybackSettings `protobuf:"bytes,1,opt,name=settings" json:"settings,omitempty"`
+}
+
+func (m *GetPlaybackSettingsResponse) Reset()                    { *m = GetPlaybackSettingsResponse{} }
+func (m *GetPlaybackSettingsResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetPlaybackSettingsResponse) ProtoMessage()               {}
+func (*GetPlaybackSettingsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *GetPlaybackSettingsResponse) GetSettings() *PlaybackSettings {
+	if m != nil {
+		return m.Settings
+	}
+	return nil
+}
+
+type SetPlaybackSettingsRequest struct {
+	Settings *PlaybackSettings `protobuf:"bytes,1,opt,name=settings" json:"settings,omitempty"`
+}
+
+func (m *SetPlaybackSettingsRequest) Reset()                    { *m = SetPlaybackSettingsRequest{} }
+func (m *SetPlaybackSettingsRequest) String() string            { return proto.CompactTextString(m) }
+func (*SetPlaybackSettingsRequest) ProtoMessage()               {}
+func (*SetPlaybackSettingsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *SetPlaybackSettingsRequest) GetSettings() *PlaybackSettings {
+	if m != nil {
+		return m.Settings
+	}
+	return nil
+}
+
+type SetPlaybackSettingsResponse struct {
+}
+
+func (m *SetPlaybackSettingsResponse) Reset()                    { *m = SetPlaybackSettingsResponse{} }
+func (m *SetPlaybackSettingsResponse) String() string            { return proto.CompactTextString(m) }
+func (*SetPlaybackSettingsResponse) ProtoMessage()               {}
+func (*SetPlaybackSettingsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
 
 type GetPlayingRequest struct {
 }
@@ -109,16 +823,22 @@ type GetPlayingRequest struct {
 func (m *GetPlayingRequest) Reset()                    { *m = GetPlayingRequest{} }
 func (m *GetPlayingRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetPlayingRequest) ProtoMessage()               {}
-func (*GetPlayingRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*GetPlayingRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
 
 type GetPlayingResponse struct {
 	Song *Song `protobuf:"bytes,1,opt,name=song" json:"song,omitempty"`
+	// Whether playback is playing, paused, or stopped.
+	State PlayState `protobuf:"varint,2,opt,name=state,enum=Playsource.PlayState" json:"state,omitempty"`
+	// Elapsed position into the song, in milliseconds.
+	PositionMs int32 `protobuf:"varint,3,opt,name=position_ms" json:"position_ms,omitempty"`
+	// Length of the song, in milliseconds.
+	LengthMs int32 `protobuf:"varint,4,opt,name=length_ms" json:"length_ms,omitempty"`
 }
 
 func (m *GetPlayingResponse) Reset()                    { *m = GetPlayingResponse{} }
 func (m *GetPlayingResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetPlayingResponse) ProtoMessage()               {}
-func (*GetPlayingResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*GetPlayingResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
 
 func (m *GetPlayingResponse) GetSong() *Song {
 	if m != nil {
@@ -128,21 +848,37 @@ func (m *GetPlayingResponse) GetSong() *Song {
 }
 
 type GetPlayHistoryRequest struct {
+	// Only return songs that started playing at or after this time,
+	// in milliseconds since the unix epoch. Unbounded if 0.
+	SinceMs int64 `protobuf:"varint,1,opt,name=since_ms" json:"since_ms,omitempty"`
+	// Only return songs that started playing before this time,
+	// in milliseconds since the unix epoch. Unbounded if 0.
+	UntilMs int64 `protobuf:"varint,2,opt,name=until_ms" json:"until_ms,omitempty"`
+	// Only return the most recent songs. Unbounded if 0.
+	Limit int32 `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
 }
 
 func (m *GetPlayHistoryRequest) Reset()                    { *m = GetPlayHistoryRequest{} }
 func (m *GetPlayHistoryRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetPlayHistoryRequest) ProtoMessage()               {}
-func (*GetPlayHistoryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*GetPlayHistoryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
 
 type GetPlayHistoryResponse struct {
 	Song *Song `protobuf:"bytes,1,opt,name=song" json:"song,omitempty"`
+	// When the song started and finished playing, in milliseconds
+	// since the unix epoch.
+	StartedMs  int64 `protobuf:"varint,2,opt,name=started_ms" json:"started_ms,omitempty"`
+	FinishedMs int64 `protobuf:"varint,3,opt,name=finished_ms" json:"finished_ms,omitempty"`
+	// The URI of the track that was played for the song.
+	Uri string `protobuf:"bytes,4,opt,name=uri" json:"uri,omitempty"`
+	// Whether or not the song was skipped.
+	Skipped bool `protobuf:"varint,5,opt,name=skipped" json:"skipped,omitempty"`
 }
 
 func (m *GetPlayHistoryResponse) Reset()                    { *m = GetPlayHistoryResponse{} }
 func (m *GetPlayHistoryResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetPlayHistoryResponse) ProtoMessage()               {}
-func (*GetPlayHistoryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*GetPlayHistoryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
 
 func (m *GetPlayHistoryResponse) GetSong() *Song {
 	if m != nil {
@@ -151,16 +887,234 @@ func (m *GetPlayHistoryResponse) GetSong() *Song {
 	return nil
 }
 
+type SearchRequest struct {
+	// Fields to search by. At least one must be set.
+	TrackName string   `protobuf:"bytes,1,opt,name=track_name" json:"track_name,omitempty"`
+	Artists   []string `protobuf:"bytes,2,rep,name=artists" json:"artists,omitempty"`
+	Genre     string   `protobuf:"bytes,3,opt,name=genre" json:"genre,omitempty"`
+	Album     string   `protobuf:"bytes,4,opt,name=album" json:"album,omitempty"`
+	// Matches any field.
+	Any string `protobuf:"bytes,5,opt,name=any" json:"any,omitempty"`
+	// Number of results to skip.
+	Offset int32 `protobuf:"varint,6,opt,name=offset" json:"offset,omitempty"`
+	// Maximum number of results to return. Unbounded if 0.
+	Limit int32 `protobuf:"varint,7,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *SearchRequest) Reset()                    { *m = SearchRequest{} }
+func (m *SearchRequest) String() string            { return proto.CompactTextString(m) }
+func (*SearchRequest) ProtoMessage()               {}
+func (*SearchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+type SearchResponse struct {
+	Tracks []*Track `protobuf:"bytes,1,rep,name=tracks" json:"tracks,omitempty"`
+	// Total number of results, regardless of offset and limit.
+	Total int32 `protobuf:"varint,2,opt,name=total" json:"total,omitempty"`
+}
+
+func (m *SearchResponse) Reset()                    { *m = SearchResponse{} }
+func (m *SearchResponse) String() string            { return proto.CompactTextString(m) }
+func (*SearchResponse) ProtoMessage()               {}
+func (*SearchResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+
+func (m *SearchResponse) GetTracks() []*Track {
+	if m != nil {
+		return m.Tracks
+	}
+	return nil
+}
+
+type WatchPlaybackRequest struct {
+	// Replay the buffered events after this sequence number. If 0,
+	// only new events are sent.
+	AfterSequence uint64 `protobuf:"varint,1,opt,name=after_sequence" json:"after_sequence,omitempty"`
+	// The epoch of the event after_sequence came from. If it isn't the
+	// current epoch, the sequence number is from before a restart, and
+	// every buffered event is replayed.
+	Epoch string `protobuf:"bytes,2,opt,name=epoch" json:"epoch,omitempty"`
+}
+
+func (m *WatchPlaybackRequest) Reset()                    { *m = WatchPlaybackRequest{} }
+func (m *WatchPlaybackRequest) String() string            { return proto.CompactTextString(m) }
+func (*WatchPlaybackRequest) ProtoMessage()               {}
+func (*WatchPlaybackRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+type PlaybackEvent struct {
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence" json:"sequence,omitempty"`
+	// When the event happened, in milliseconds since the unix epoch.
+	TimestampMs int64              `protobuf:"varint,2,opt,name=timestamp_ms" json:"timestamp_ms,omitempty"`
+	Type        PlaybackEvent_Type `protobuf:"varint,3,opt,name=type,enum=Playsource.PlaybackEvent_Type" json:"type,omitempty"`
+	// The song and track the event is about, for TRACK_STARTED, TRACK_FINISHED,
+	// TRACK_FAILED and SKIPPED. The song is only set if it was queued through
+	// the playsource.
+	Song  *Song  `protobuf:"bytes,4,opt,name=song" json:"song,omitempty"`
+	Track *Track `protobuf:"bytes,5,opt,name=track" json:"track,omitempty"`
+	// The volume after a VOLUME_CHANGED event, from 0 to 100.
+	Volume int32 `protobuf:"varint,6,opt,name=volume" json:"volume,omitempty"`
+	Muted  bool  `protobuf:"varint,7,opt,name=muted" json:"muted,omitempty"`
+	// Why the track failed, for TRACK_FAILED.
+	Error string `protobuf:"bytes,8,opt,name=error" json:"error,omitempty"`
+	// Why the track was skipped, and who skipped it, for SKIPPED. The
+	// requester is the one whose vote reached the threshold, if skips
+	// are voted on.
+	Reason    string `protobuf:"bytes,9,opt,name=reason" json:"reason,omitempty"`
+	Requester string `protobuf:"bytes,10,opt,name=requester" json:"requester,omitempty"`
+	// The playback state after a PAUSED, RESUMED or STOPPED event.
+	State PlayState `protobuf:"varint,11,opt,name=state,enum=Playsource.PlayState" json:"state,omitempty"`
+	// Identifies the run of the playsource the event is from. Sequence
+	// numbers are only comparable between events of the same epoch.
+	Epoch string `protobuf:"bytes,12,opt,name=epoch" json:"epoch,omitempty"`
+}
+
+func (m *PlaybackEvent) Reset()                    { *m = PlaybackEvent{} }
+func (m *PlaybackEvent) String() string            { return proto.CompactTextString(m) }
+func (*PlaybackEvent) ProtoMessage()               {}
+func (*PlaybackEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+
+func (m *PlaybackEvent) GetSong() *Song {
+	if m != nil {
+		return m.Song
+	}
+	return nil
+}
+
+func (m *PlaybackEvent) GetTrack() *Track {
+	if m != nil {
+		return m.Track
+	}
+	return nil
+}
+
+// Faults are the failures and latency the simulated playsource injects.
+type Faults struct {
+	// Probability, from 0 to 1, that a song is found.
+	FoundProbability float64 `protobuf:"fixed64,1,opt,name=found_probability" json:"found_probability,omitempty"`
+	// Probability, from 0 to 1, that a queued song fails to play.
+	FailureProbability float64 `protobuf:"fixed64,2,opt,name=failure_probability" json:"failure_probability,omitempty"`
+	// Songs that fail to play, regardless of failure_probability.
+	FailingSongIds []int32 `protobuf:"varint,3,rep,name=failing_song_ids,packed" json:"failing_song_ids,omitempty"`
+	// Delay before every Playsource RPC is handled, and before each song is
+	// looked up, in milliseconds.
+	LatencyMs int32 `protobuf:"varint,4,opt,name=latency_ms" json:"latency_ms,omitempty"`
+	// Probability, from 0 to 1, that a Playsource RPC fails with UNAVAILABLE,
+	// as if the playback system couldn't be reached. QueueSong streams fail
+	// when they're opened.
+	ErrorProbability float64 `protobuf:"fixed64,5,opt,name=error_probability" json:"error_probability,omitempty"`
+}
+
+func (m *Faults) Reset()                    { *m = Faults{} }
+func (m *Faults) String() string            { return proto.CompactTextString(m) }
+func (*Faults) ProtoMessage()               {}
+func (*Faults) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{49} }
+
+type GetFaultsRequest struct {
+}
+
+func (m *GetFaultsRequest) Reset()                    { *m = GetFaultsRequest{} }
+func (m *GetFaultsRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetFaultsRequest) ProtoMessage()               {}
+func (*GetFaultsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{50} }
+
+type GetFaultsResponse struct {
+	Faults *Faults `protobuf:"bytes,1,opt,name=faults" json:"faults,omitempty"`
+}
+
+func (m *GetFaultsResponse) Reset()                    { *m = GetFaultsResponse{} }
+func (m *GetFaultsResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetFaultsResponse) ProtoMessage()               {}
+func (*GetFaultsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{51} }
+
+func (m *GetFaultsResponse) GetFaults() *Faults {
+	if m != nil {
+		return m.Faults
+	}
+	return nil
+}
+
+type SetFaultsRequest struct {
+	Faults *Faults `protobuf:"bytes,1,opt,name=faults" json:"faults,omitempty"`
+}
+
+func (m *SetFaultsRequest) Reset()                    { *m = SetFaultsRequest{} }
+func (m *SetFaultsRequest) String() string            { return proto.CompactTextString(m) }
+func (*SetFaultsRequest) ProtoMessage()               {}
+func (*SetFaultsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{52} }
+
+func (m *SetFaultsRequest) GetFaults() *Faults {
+	if m != nil {
+		return m.Faults
+	}
+	return nil
+}
+
+type SetFaultsResponse struct {
+}
+
+func (m *SetFaultsResponse) Reset()                    { *m = SetFaultsResponse{} }
+func (m *SetFaultsResponse) String() string            { return proto.CompactTextString(m) }
+func (*SetFaultsResponse) ProtoMessage()               {}
+func (*SetFaultsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{53} }
+
 func init() {
 	proto.RegisterType((*Song)(nil), "Playsource.Song")
+	proto.RegisterType((*Track)(nil), "Playsource.Track")
 	proto.RegisterType((*QueueSongRequest)(nil), "Playsource.QueueSongRequest")
+	proto.RegisterType((*Handshake)(nil), "Playsource.Handshake")
+	proto.RegisterType((*Lease)(nil), "Playsource.Lease")
+	proto.RegisterType((*Session)(nil), "Playsource.Session")
 	proto.RegisterType((*QueueSongResponse)(nil), "Playsource.QueueSongResponse")
 	proto.RegisterType((*SkipSongRequest)(nil), "Playsource.SkipSongRequest")
 	proto.RegisterType((*SkipSongResponse)(nil), "Playsource.SkipSongResponse")
+	proto.RegisterType((*PauseRequest)(nil), "Playsource.PauseRequest")
+	proto.RegisterType((*PauseResponse)(nil), "Playsource.PauseResponse")
+	proto.RegisterType((*ResumeRequest)(nil), "Playsource.ResumeRequest")
+	proto.RegisterType((*ResumeResponse)(nil), "Playsource.ResumeResponse")
+	proto.RegisterType((*StopRequest)(nil), "Playsource.StopRequest")
+	proto.RegisterType((*StopResponse)(nil), "Playsource.StopResponse")
+	proto.RegisterType((*SeekRequest)(nil), "Playsource.SeekRequest")
+	proto.RegisterType((*SeekResponse)(nil), "Playsource.SeekResponse")
+	proto.RegisterType((*PreviousRequest)(nil), "Playsource.PreviousRequest")
+	proto.RegisterType((*PreviousResponse)(nil), "Playsource.PreviousResponse")
+	proto.RegisterType((*QueueEntry)(nil), "Playsource.QueueEntry")
+	proto.RegisterType((*ListQueueRequest)(nil), "Playsource.ListQueueRequest")
+	proto.RegisterType((*ListQueueResponse)(nil), "Playsource.ListQueueResponse")
+	proto.RegisterType((*RemoveFromQueueRequest)(nil), "Playsource.RemoveFromQueueRequest")
+	proto.RegisterType((*RemoveFromQueueResponse)(nil), "Playsource.RemoveFromQueueResponse")
+	proto.RegisterType((*MoveInQueueRequest)(nil), "Playsource.MoveInQueueRequest")
+	proto.RegisterType((*MoveInQueueResponse)(nil), "Playsource.MoveInQueueResponse")
+	proto.RegisterType((*ClearQueueRequest)(nil), "Playsource.ClearQueueRequest")
+	proto.RegisterType((*ClearQueueResponse)(nil), "Playsource.ClearQueueResponse")
+	proto.RegisterType((*GetVolumeRequest)(nil), "Playsource.GetVolumeRequest")
+	proto.RegisterType((*GetVolumeResponse)(nil), "Playsource.GetVolumeResponse")
+	proto.RegisterType((*SetVolumeRequest)(nil), "Playsource.SetVolumeRequest")
+	proto.RegisterType((*SetVolumeResponse)(nil), "Playsource.SetVolumeResponse")
+	proto.RegisterType((*SetMuteRequest)(nil), "Playsource.SetMuteRequest")
+	proto.RegisterType((*SetMuteResponse)(nil), "Playsource.SetMuteResponse")
+	proto.RegisterType((*VoteVolumeRequest)(nil), "Playsource.VoteVolumeRequest")
+	proto.RegisterType((*VoteVolumeResponse)(nil), "Playsource.VoteVolumeResponse")
+	proto.RegisterType((*PlaybackSettings)(nil), "Playsource.PlaybackSettings")
+	proto.RegisterType((*GetPlaybackSettingsRequest)(nil), "Playsource.GetPlaybackSettingsRequest")
+	proto.RegisterType((*GetPlaybackSettingsResponse)(nil), "Playsource.GetPlaybackSettingsResponse")
+	proto.RegisterType((*SetPlaybackSettingsRequest)(nil), "Playsource.SetPlaybackSettingsRequest")
+	proto.RegisterType((*SetPlaybackSettingsResponse)(nil), "Playsource.SetPlaybackSettingsResponse")
 	proto.RegisterType((*GetPlayingRequest)(nil), "Playsource.GetPlayingRequest")
 	proto.RegisterType((*GetPlayingResponse)(nil), "Playsource.GetPlayingResponse")
 	proto.RegisterType((*GetPlayHistoryRequest)(nil), "Playsource.GetPlayHistoryRequest")
 	proto.RegisterType((*GetPlayHistoryResponse)(nil), "Playsource.GetPlayHistoryResponse")
+	proto.RegisterType((*SearchRequest)(nil), "Playsource.SearchRequest")
+	proto.RegisterType((*SearchResponse)(nil), "Playsource.SearchResponse")
+	proto.RegisterType((*WatchPlaybackRequest)(nil), "Playsource.WatchPlaybackRequest")
+	proto.RegisterType((*PlaybackEvent)(nil), "Playsource.PlaybackEvent")
+	proto.RegisterType((*Faults)(nil), "Playsource.Faults")
+	proto.RegisterType((*GetFaultsRequest)(nil), "Playsource.GetFaultsRequest")
+	proto.RegisterType((*GetFaultsResponse)(nil), "Playsource.GetFaultsResponse")
+	proto.RegisterType((*SetFaultsRequest)(nil), "Playsource.SetFaultsRequest")
+	proto.RegisterType((*SetFaultsResponse)(nil), "Playsource.SetFaultsResponse")
+	proto.RegisterEnum("Playsource.PlayState", PlayState_name, PlayState_value)
+	proto.RegisterEnum("Playsource.QueueSongRequest_Insertion", QueueSongRequest_Insertion_name, QueueSongRequest_Insertion_value)
+	proto.RegisterEnum("Playsource.QueueSongResponse_Reason", QueueSongResponse_Reason_name, QueueSongResponse_Reason_value)
+	proto.RegisterEnum("Playsource.VoteVolumeRequest_Direction", VoteVolumeRequest_Direction_name, VoteVolumeRequest_Direction_value)
+	proto.RegisterEnum("Playsource.PlaybackEvent_Type", PlaybackEvent_Type_name, PlaybackEvent_Type_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -175,10 +1129,21 @@ type PlaysourceClient interface {
 	// song was able to be queued (server queue full, not found, etc), or when
 	// a song was finished.
 	//
-	// Only one QueueSong() stream may be in progress at a given time.
+	// Only one QueueSong() stream, the primary, may control the playsource at a
+	// given time. The primary holds a lease, identified by a fencing token, which
+	// it keeps by sending requests (songs, or empty heartbeats) more often than the
+	// lease timeout. If the primary stops heartbeating, another controller may
+	// acquire the lease. A controller may also explicitly take the lease over from
+	// a live primary, in which case the old primary's stream is aborted.
+	//
+	// Any number of observers may open QueueSong() streams as well. Observers
+	// receive every response the primary does, but may not queue songs.
 	//
 	// When a QueueSong() stream is successfully opened, the playsource resets
-	// the playback system, stopping what's playing, and clearing the queues.
+	// the playback system, stopping what's playing, and clearing the queues,
+	// unless the handshake resumes the existing session. Resuming leaves playback
+	// untouched, and the songs that finished while the primary was disconnected
+	// are replayed on the new stream.
 	//
 	// When a QueueSong() stream finishes, the state remains until another stream
 	// is opened.
@@ -187,10 +1152,73 @@ type PlaysourceClient interface {
 	// have a queue size that is smaller. The internal queue size limitation is
 	// a safety measure to ensure the playback system can handle the queue.
 	QueueSong(ctx context.Context, opts ...grpc.CallOption) (Playsource_QueueSongClient, error)
+	// SkipSong skips the playing song. If skips are voted on, it only
+	// counts as a vote until enough requesters have asked.
 	SkipSong(ctx context.Context, in *SkipSongRequest, opts ...grpc.CallOption) (*SkipSongResponse, error)
+	// Pause pauses playback.
+	Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PauseResponse, error)
+	// Resume resumes paused playback, or starts stopped playback over.
+	Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*ResumeResponse, error)
+	// Stop stops playback. The current song is kept, and starts over
+	// when playback is resumed.
+	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error)
+	// Seek seeks to a position in the current song.
+	Seek(ctx context.Context, in *SeekRequest, opts ...grpc.CallOption) (*SeekResponse, error)
+	// Previous plays the previous song. If there isn't one, the current
+	// song starts over.
+	Previous(ctx context.Context, in *PreviousRequest, opts ...grpc.CallOption) (*PreviousResponse, error)
+	// ListQueue returns the playback system's queue, in the order it will be
+	// played, starting with the song that's playing, if any.
+	ListQueue(ctx context.Context, in *ListQueueRequest, opts ...grpc.CallOption) (*ListQueueResponse, error)
+	// RemoveFromQueue removes a queued song. Removed songs are not reported on
+	// the QueueSong stream. The playing song can't be removed; skip it instead.
+	RemoveFromQueue(ctx context.Context, in *RemoveFromQueueRequest, opts ...grpc.CallOption) (*RemoveFromQueueResponse, error)
+	// MoveInQueue moves a queued song to a position in the queue, as returned by
+	// ListQueue. Songs can't be moved before the playing song, nor can the
+	// playing song be moved.
+	MoveInQueue(ctx context.Context, in *MoveInQueueRequest, opts ...grpc.CallOption) (*MoveInQueueResponse, error)
+	// ClearQueue removes everything from the queue, except for the playing song.
+	// Like RemoveFromQueue, removed songs are not reported on the QueueSong stream.
+	//
+	// Only the primary may change the queue, so RemoveFromQueue, MoveInQueue and
+	// ClearQueue fail with PERMISSION_DENIED unless they carry its lease token.
+	ClearQueue(ctx context.Context, in *ClearQueueRequest, opts ...grpc.CallOption) (*ClearQueueResponse, error)
+	// GetVolume returns the volume of the playback system.
+	GetVolume(ctx context.Context, in *GetVolumeRequest, opts ...grpc.CallOption) (*GetVolumeResponse, error)
+	// SetVolume sets the volume of the playback system.
+	SetVolume(ctx context.Context, in *SetVolumeRequest, opts ...grpc.CallOption) (*SetVolumeResponse, error)
+	// SetMute mutes or unmutes the playback system.
+	SetMute(ctx context.Context, in *SetMuteRequest, opts ...grpc.CallOption) (*SetMuteResponse, error)
+	// VoteVolume registers a listener's vote to turn the volume up or down.
+	// Once enough distinct listeners agree within a window of time, the volume
+	// is changed, and the votes are cleared.
+	VoteVolume(ctx context.Context, in *VoteVolumeRequest, opts ...grpc.CallOption) (*VoteVolumeResponse, error)
+	// GetPlaybackSettings returns the settings for transitions between songs.
+	GetPlaybackSettings(ctx context.Context, in *GetPlaybackSettingsRequest, opts ...grpc.CallOption) (*GetPlaybackSettingsResponse, error)
+	// SetPlaybackSettings replaces the settings for transitions between songs.
+	// Since every setting is replaced, callers should modify the settings
+	// returned by GetPlaybackSettings.
+	SetPlaybackSettings(ctx context.Context, in *SetPlaybackSettingsRequest, opts ...grpc.CallOption) (*SetPlaybackSettingsResponse, error)
 	// GetPlaying returns the currently playing song (if any).
 	GetPlaying(ctx context.Context, in *GetPlayingRequest, opts ...grpc.CallOption) (*GetPlayingResponse, error)
-	// GetPlayHistory returns songs that have been played since the service has been running.
+	// Search searches the playback system's library for tracks, so that
+	// callers can check whether a song can be resolved before queueing it.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// WatchPlayback streams playback events as they happen. Every event has a
+	// sequence number, which increases by one with each event. A watcher that
+	// reconnects can pass the last sequence number it saw to have the events it
+	// missed replayed, as long as they're still buffered. If they aren't, the
+	// stream starts with the oldest buffered event, so the gap is visible from
+	// the sequence numbers. Sequence numbers start over when the playsource is
+	// restarted, which is told apart by the epoch each event carries. Watchers
+	// that pass an epoch other than the current one have all buffered events
+	// replayed.
+	//
+	// Watchers that can't keep up have their stream aborted, and should resume
+	// from the last sequence number they saw.
+	WatchPlayback(ctx context.Context, in *WatchPlaybackRequest, opts ...grpc.CallOption) (Playsource_WatchPlaybackClient, error)
+	// GetPlayHistory returns songs that have been played, oldest first. History
+	// is persisted, so it includes songs played before the service was restarted.
 	GetPlayHistory(ctx context.Context, in *GetPlayHistoryRequest, opts ...grpc.CallOption) (Playsource_GetPlayHistoryClient, error)
 }
 
@@ -242,17 +1270,193 @@ func (c *playsourceClient) SkipSong(ctx context.Context, in *SkipSongRequest, op
 	return out, nil
 }
 
-func (c *playsourceClient) GetPlaying(ctx context.Context, in *GetPlayingRequest, opts ...grpc.CallOption) (*GetPlayingResponse, error) {
-	out := new(GetPlayingResponse)
-	err := grpc.Invoke(ctx, "/Playsource.Playsource/GetPlaying", in, out, c.cc, opts...)
-	if err != nil {
+func (c *playsourceClient) Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PauseResponse, error) {
+	out := new(PauseResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/Pause", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*ResumeResponse, error) {
+	out := new(ResumeResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/Resume", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error) {
+	out := new(StopResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/Stop", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) Seek(ctx context.Context, in *SeekRequest, opts ...grpc.CallOption) (*SeekResponse, error) {
+	out := new(SeekResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/Seek", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) Previous(ctx context.Context, in *PreviousRequest, opts ...grpc.CallOption) (*PreviousResponse, error) {
+	out := new(PreviousResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/Previous", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) ListQueue(ctx context.Context, in *ListQueueRequest, opts ...grpc.CallOption) (*ListQueueResponse, error) {
+	out := new(ListQueueResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/ListQueue", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) RemoveFromQueue(ctx context.Context, in *RemoveFromQueueRequest, opts ...grpc.CallOption) (*RemoveFromQueueResponse, error) {
+	out := new(RemoveFromQueueResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/RemoveFromQueue", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) MoveInQueue(ctx context.Context, in *MoveInQueueRequest, opts ...grpc.CallOption) (*MoveInQueueResponse, error) {
+	out := new(MoveInQueueResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/MoveInQueue", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) ClearQueue(ctx context.Context, in *ClearQueueRequest, opts ...grpc.CallOption) (*ClearQueueResponse, error) {
+	out := new(ClearQueueResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/ClearQueue", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) GetVolume(ctx context.Context, in *GetVolumeRequest, opts ...grpc.CallOption) (*GetVolumeResponse, error) {
+	out := new(GetVolumeResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/GetVolume", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) SetVolume(ctx context.Context, in *SetVolumeRequest, opts ...grpc.CallOption) (*SetVolumeResponse, error) {
+	out := new(SetVolumeResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/SetVolume", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) SetMute(ctx context.Context, in *SetMuteRequest, opts ...grpc.CallOption) (*SetMuteResponse, error) {
+	out := new(SetMuteResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/SetMute", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) VoteVolume(ctx context.Context, in *VoteVolumeRequest, opts ...grpc.CallOption) (*VoteVolumeResponse, error) {
+	out := new(VoteVolumeResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/VoteVolume", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) GetPlaybackSettings(ctx context.Context, in *GetPlaybackSettingsRequest, opts ...grpc.CallOption) (*GetPlaybackSettingsResponse, error) {
+	out := new(GetPlaybackSettingsResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/GetPlaybackSettings", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) SetPlaybackSettings(ctx context.Context, in *SetPlaybackSettingsRequest, opts ...grpc.CallOption) (*SetPlaybackSettingsResponse, error) {
+	out := new(SetPlaybackSettingsResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/SetPlaybackSettings", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) GetPlaying(ctx context.Context, in *GetPlayingRequest, opts ...grpc.CallOption) (*GetPlayingResponse, error) {
+	out := new(GetPlayingResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/GetPlaying", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	err := grpc.Invoke(ctx, "/Playsource.Playsource/Search", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playsourceClient) WatchPlayback(ctx context.Context, in *WatchPlaybackRequest, opts ...grpc.CallOption) (Playsource_WatchPlaybackClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Playsource_serviceDesc.Streams[1], c.cc, "/Playsource.Playsource/WatchPlayback", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &playsourceWatchPlaybackClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Playsource_WatchPlaybackClient interface {
+	Recv() (*PlaybackEvent, error)
+	grpc.ClientStream
+}
+
+type playsourceWatchPlaybackClient struct {
+	grpc.ClientStream
+}
+
+func (x *playsourceWatchPlaybackClient) Recv() (*PlaybackEvent, error) {
+	m := new(PlaybackEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
-	return out, nil
+	return m, nil
 }
 
 func (c *playsourceClient) GetPlayHistory(ctx context.Context, in *GetPlayHistoryRequest, opts ...grpc.CallOption) (Playsource_GetPlayHistoryClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_Playsource_serviceDesc.Streams[1], c.cc, "/Playsource.Playsource/GetPlayHistory", opts...)
+	stream, err := grpc.NewClientStream(ctx, &_Playsource_serviceDesc.Streams[2], c.cc, "/Playsource.Playsource/GetPlayHistory", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -291,10 +1495,21 @@ type PlaysourceServer interface {
 	// song was able to be queued (server queue full, not found, etc), or when
 	// a song was finished.
 	//
-	// Only one QueueSong() stream may be in progress at a given time.
+	// Only one QueueSong() stream, the primary, may control the playsource at a
+	// given time. The primary holds a lease, identified by a fencing token, which
+	// it keeps by sending requests (songs, or empty heartbeats) more often than the
+	// lease timeout. If the primary stops heartbeating, another controller may
+	// acquire the lease. A controller may also explicitly take the lease over from
+	// a live primary, in which case the old primary's stream is aborted.
+	//
+	// Any number of observers may open QueueSong() streams as well. Observers
+	// receive every response the primary does, but may not queue songs.
 	//
 	// When a QueueSong() stream is successfully opened, the playsource resets
-	// the playback system, stopping what's playing, and clearing the queues.
+	// the playback system, stopping what's playing, and clearing the queues,
+	// unless the handshake resumes the existing session. Resuming leaves playback
+	// untouched, and the songs that finished while the primary was disconnected
+	// are replayed on the new stream.
 	//
 	// When a QueueSong() stream finishes, the state remains until another stream
 	// is opened.
@@ -303,10 +1518,73 @@ type PlaysourceServer interface {
 	// have a queue size that is smaller. The internal queue size limitation is
 	// a safety measure to ensure the playback system can handle the queue.
 	QueueSong(Playsource_QueueSongServer) error
+	// SkipSong skips the playing song. If skips are voted on, it only
+	// counts as a vote until enough requesters have asked.
 	SkipSong(context.Context, *SkipSongRequest) (*SkipSongResponse, error)
+	// Pause pauses playback.
+	Pause(context.Context, *PauseRequest) (*PauseResponse, error)
+	// Resume resumes paused playback, or starts stopped playback over.
+	Resume(context.Context, *ResumeRequest) (*ResumeResponse, error)
+	// Stop stops playback. The current song is kept, and starts over
+	// when playback is resumed.
+	Stop(context.Context, *StopRequest) (*StopResponse, error)
+	// Seek seeks to a position in the current song.
+	Seek(context.Context, *SeekRequest) (*SeekResponse, error)
+	// Previous plays the previous song. If there isn't one, the current
+	// song starts over.
+	Previous(context.Context, *PreviousRequest) (*PreviousResponse, error)
+	// ListQueue returns the playback system's queue, in the order it will be
+	// played, starting with the song that's playing, if any.
+	ListQueue(context.Context, *ListQueueRequest) (*ListQueueResponse, error)
+	// RemoveFromQueue removes a queued song. Removed songs are not reported on
+	// the QueueSong stream. The playing song can't be removed; skip it instead.
+	RemoveFromQueue(context.Context, *RemoveFromQueueRequest) (*RemoveFromQueueResponse, error)
+	// MoveInQueue moves a queued song to a position in the queue, as returned by
+	// ListQueue. Songs can't be moved before the playing song, nor can the
+	// playing song be moved.
+	MoveInQueue(context.Context, *MoveInQueueRequest) (*MoveInQueueResponse, error)
+	// ClearQueue removes everything from the queue, except for the playing song.
+	// Like RemoveFromQueue, removed songs are not reported on the QueueSong stream.
+	//
+	// Only the primary may change the queue, so RemoveFromQueue, MoveInQueue and
+	// ClearQueue fail with PERMISSION_DENIED unless they carry its lease token.
+	ClearQueue(context.Context, *ClearQueueRequest) (*ClearQueueResponse, error)
+	// GetVolume returns the volume of the playback system.
+	GetVolume(context.Context, *GetVolumeRequest) (*GetVolumeResponse, error)
+	// SetVolume sets the volume of the playback system.
+	SetVolume(context.Context, *SetVolumeRequest) (*SetVolumeResponse, error)
+	// SetMute mutes or unmutes the playback system.
+	SetMute(context.Context, *SetMuteRequest) (*SetMuteResponse, error)
+	// VoteVolume registers a listener's vote to turn the volume up or down.
+	// Once enough distinct listeners agree within a window of time, the volume
+	// is changed, and the votes are cleared.
+	VoteVolume(context.Context, *VoteVolumeRequest) (*VoteVolumeResponse, error)
+	// GetPlaybackSettings returns the settings for transitions between songs.
+	GetPlaybackSettings(context.Context, *GetPlaybackSettingsRequest) (*GetPlaybackSettingsResponse, error)
+	// SetPlaybackSettings replaces the settings for transitions between songs.
+	// Since every setting is replaced, callers should modify the settings
+	// returned by GetPlaybackSettings.
+	SetPlaybackSettings(context.Context, *SetPlaybackSettingsRequest) (*SetPlaybackSettingsResponse, error)
 	// GetPlaying returns the currently playing song (if any).
 	GetPlaying(context.Context, *GetPlayingRequest) (*GetPlayingResponse, error)
-	// GetPlayHistory returns songs that have been played since the service has been running.
+	// Search searches the playback system's library for tracks, so that
+	// callers can check whether a song can be resolved before queueing it.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// WatchPlayback streams playback events as they happen. Every event has a
+	// sequence number, which increases by one with each event. A watcher that
+	// reconnects can pass the last sequence number it saw to have the events it
+	// missed replayed, as long as they're still buffered. If they aren't, the
+	// stream starts with the oldest buffered event, so the gap is visible from
+	// the sequence numbers. Sequence numbers start over when the playsource is
+	// restarted, which is told apart by the epoch each event carries. Watchers
+	// that pass an epoch other than the current one have all buffered events
+	// replayed.
+	//
+	// Watchers that can't keep up have their stream aborted, and should resume
+	// from the last sequence number they saw.
+	WatchPlayback(*WatchPlaybackRequest, Playsource_WatchPlaybackServer) error
+	// GetPlayHistory returns songs that have been played, oldest first. History
+	// is persisted, so it includes songs played before the service was restarted.
 	GetPlayHistory(*GetPlayHistoryRequest, Playsource_GetPlayHistoryServer) error
 }
 
@@ -352,6 +1630,186 @@ func _Playsource_SkipSong_Handler(srv interface{}, ctx context.Context, dec func
 	return out, nil
 }
 
+func _Playsource_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).Pause(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_Resume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(ResumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).Resume(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(StopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).Stop(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_Seek_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(SeekRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).Seek(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_Previous_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(PreviousRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).Previous(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_ListQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(ListQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).ListQueue(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_RemoveFromQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(RemoveFromQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).RemoveFromQueue(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_MoveInQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(MoveInQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).MoveInQueue(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_ClearQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(ClearQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).ClearQueue(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_GetVolume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(GetVolumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).GetVolume(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_SetVolume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(SetVolumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).SetVolume(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_SetMute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(SetMuteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).SetMute(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_VoteVolume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(VoteVolumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).VoteVolume(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_GetPlaybackSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(GetPlaybackSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).GetPlaybackSettings(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_SetPlaybackSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(SetPlaybackSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).SetPlaybackSettings(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func _Playsource_GetPlaying_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
 	in := new(GetPlayingRequest)
 	if err := dec(in); err != nil {
@@ -364,6 +1822,39 @@ func _Playsource_GetPlaying_Handler(srv interface{}, ctx context.Context, dec fu
 	return out, nil
 }
 
+func _Playsource_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PlaysourceServer).Search(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Playsource_WatchPlayback_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPlaybackRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PlaysourceServer).WatchPlayback(m, &playsourceWatchPlaybackServer{stream})
+}
+
+type Playsource_WatchPlaybackServer interface {
+	Send(*PlaybackEvent) error
+	grpc.ServerStream
+}
+
+type playsourceWatchPlaybackServer struct {
+	grpc.ServerStream
+}
+
+func (x *playsourceWatchPlaybackServer) Send(m *PlaybackEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Playsource_GetPlayHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(GetPlayHistoryRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -393,10 +1884,74 @@ var _Playsource_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SkipSong",
 			Handler:    _Playsource_SkipSong_Handler,
 		},
+		{
+			MethodName: "Pause",
+			Handler:    _Playsource_Pause_Handler,
+		},
+		{
+			MethodName: "Resume",
+			Handler:    _Playsource_Resume_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _Playsource_Stop_Handler,
+		},
+		{
+			MethodName: "Seek",
+			Handler:    _Playsource_Seek_Handler,
+		},
+		{
+			MethodName: "Previous",
+			Handler:    _Playsource_Previous_Handler,
+		},
+		{
+			MethodName: "ListQueue",
+			Handler:    _Playsource_ListQueue_Handler,
+		},
+		{
+			MethodName: "RemoveFromQueue",
+			Handler:    _Playsource_RemoveFromQueue_Handler,
+		},
+		{
+			MethodName: "MoveInQueue",
+			Handler:    _Playsource_MoveInQueue_Handler,
+		},
+		{
+			MethodName: "ClearQueue",
+			Handler:    _Playsource_ClearQueue_Handler,
+		},
+		{
+			MethodName: "GetVolume",
+			Handler:    _Playsource_GetVolume_Handler,
+		},
+		{
+			MethodName: "SetVolume",
+			Handler:    _Playsource_SetVolume_Handler,
+		},
+		{
+			MethodName: "SetMute",
+			Handler:    _Playsource_SetMute_Handler,
+		},
+		{
+			MethodName: "VoteVolume",
+			Handler:    _Playsource_VoteVolume_Handler,
+		},
+		{
+			MethodName: "GetPlaybackSettings",
+			Handler:    _Playsource_GetPlaybackSettings_Handler,
+		},
+		{
+			MethodName: "SetPlaybackSettings",
+			Handler:    _Playsource_SetPlaybackSettings_Handler,
+		},
 		{
 			MethodName: "GetPlaying",
 			Handler:    _Playsource_GetPlaying_Handler,
 		},
+		{
+			MethodName: "Search",
+			Handler:    _Playsource_Search_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -405,6 +1960,11 @@ var _Playsource_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "WatchPlayback",
+			Handler:       _Playsource_WatchPlayback_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "GetPlayHistory",
 			Handler:       _Playsource_GetPlayHistory_Handler,
@@ -413,28 +1973,225 @@ var _Playsource_serviceDesc = grpc.ServiceDesc{
 	},
 }
 
+// Client API for TestControl service
+
+type TestControlClient interface {
+	// GetFaults returns the faults being injected.
+	GetFaults(ctx context.Context, in *GetFaultsRequest, opts ...grpc.CallOption) (*GetFaultsResponse, error)
+	// SetFaults replaces the faults being injected.
+	SetFaults(ctx context.Context, in *SetFaultsRequest, opts ...grpc.CallOption) (*SetFaultsResponse, error)
+}
+
+type testControlClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewTestControlClient(cc *grpc.ClientConn) TestControlClient {
+	return &testControlClient{cc}
+}
+
+func (c *testControlClient) GetFaults(ctx context.Context, in *GetFaultsRequest, opts ...grpc.CallOption) (*GetFaultsResponse, error) {
+	out := new(GetFaultsResponse)
+	err := grpc.Invoke(ctx, "/Playsource.TestControl/GetFaults", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *testControlClient) SetFaults(ctx context.Context, in *SetFaultsRequest, opts ...grpc.CallOption) (*SetFaultsResponse, error) {
+	out := new(SetFaultsResponse)
+	err := grpc.Invoke(ctx, "/Playsource.TestControl/SetFaults", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for TestControl service
+
+type TestControlServer interface {
+	// GetFaults returns the faults being injected.
+	GetFaults(context.Context, *GetFaultsRequest) (*GetFaultsResponse, error)
+	// SetFaults replaces the faults being injected.
+	SetFaults(context.Context, *SetFaultsRequest) (*SetFaultsResponse, error)
+}
+
+func RegisterTestControlServer(s *grpc.Server, srv TestControlServer) {
+	s.RegisterService(&_TestControl_serviceDesc, srv)
+}
+
+func _TestControl_GetFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(GetFaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(TestControlServer).GetFaults(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _TestControl_SetFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(SetFaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(TestControlServer).SetFaults(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var _TestControl_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "Playsource.TestControl",
+	HandlerType: (*TestControlServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetFaults",
+			Handler:    _TestControl_GetFaults_Handler,
+		},
+		{
+			MethodName: "SetFaults",
+			Handler:    _TestControl_SetFaults_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
 var fileDescriptor0 = []byte{
-	// 350 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x53, 0x4d, 0x4f, 0xc2, 0x40,
-	0x10, 0xb5, 0x50, 0x10, 0x46, 0x84, 0x32, 0x46, 0x25, 0x15, 0x09, 0xee, 0x45, 0x4e, 0x48, 0xd0,
-	0x18, 0xcf, 0x5e, 0xf0, 0xa2, 0x51, 0x89, 0x17, 0x3d, 0x10, 0x84, 0x05, 0x37, 0x68, 0xb7, 0x74,
-	0x5b, 0x13, 0x7e, 0xa6, 0xff, 0xc8, 0x6e, 0xed, 0xc7, 0x96, 0x0f, 0xf5, 0xd4, 0xee, 0xbc, 0x37,
-	0xef, 0xcd, 0xbc, 0x76, 0xe1, 0xd4, 0x9e, 0x4d, 0xcf, 0xec, 0xf7, 0xe1, 0x42, 0x70, 0xcf, 0x19,
-	0x51, 0xe5, 0x75, 0x20, 0xa8, 0xf3, 0xc9, 0x46, 0xb4, 0x6d, 0x3b, 0xdc, 0xe5, 0x08, 0xf7, 0x31,
-	0x42, 0x2e, 0x41, 0xef, 0x73, 0x6b, 0x8a, 0x15, 0xd8, 0x16, 0xfe, 0x73, 0xc0, 0xc6, 0x35, 0xad,
-	0xa9, 0xb5, 0x72, 0x58, 0x02, 0xdd, 0x1a, 0x7e, 0xd0, 0x5a, 0xc6, 0x3f, 0x15, 0x25, 0x3c, 0x74,
-	0x5c, 0x26, 0x5c, 0x51, 0xcb, 0x36, 0xb3, 0xad, 0x22, 0xe9, 0x82, 0xf1, 0xe0, 0x51, 0x8f, 0xca,
-	0xe6, 0x47, 0x3a, 0xf7, 0xa8, 0x70, 0xb1, 0x01, 0xba, 0xd4, 0x08, 0x04, 0x76, 0xba, 0x46, 0x3b,
-	0xb1, 0x69, 0x4b, 0x1a, 0x79, 0x82, 0xaa, 0xd2, 0x23, 0x6c, 0x6e, 0x09, 0xba, 0x6a, 0x5c, 0x86,
-	0xfc, 0x5c, 0xb2, 0xc6, 0x81, 0x75, 0x01, 0x77, 0x21, 0x37, 0xe1, 0x9e, 0x35, 0xf6, 0x8d, 0xe5,
-	0xd1, 0x80, 0xc2, 0x84, 0x59, 0x4c, 0xbc, 0xf9, 0x04, 0x5d, 0x56, 0x48, 0x15, 0x2a, 0xfd, 0x19,
-	0xb3, 0x95, 0x49, 0x08, 0x82, 0x91, 0x94, 0x7e, 0x8c, 0xc8, 0x1e, 0x54, 0x7b, 0xd4, 0x95, 0x33,
-	0xb1, 0x84, 0x78, 0x01, 0xa8, 0x16, 0xc3, 0x99, 0xfe, 0x5a, 0xe4, 0x10, 0xf6, 0xc3, 0xae, 0x1b,
-	0x3f, 0x12, 0xee, 0x2c, 0x22, 0xb9, 0x2b, 0x38, 0x58, 0x06, 0xfe, 0x27, 0xd9, 0xfd, 0xca, 0x80,
-	0xf2, 0x59, 0xf0, 0x0e, 0x8a, 0x71, 0x54, 0x58, 0x57, 0xd9, 0xcb, 0xa9, 0x9b, 0xc7, 0x1b, 0xd0,
-	0x70, 0xed, 0xad, 0x96, 0xd6, 0xd1, 0xb0, 0x07, 0x85, 0x28, 0x10, 0x3c, 0x4a, 0x99, 0xa7, 0x93,
-	0x33, 0xeb, 0xeb, 0xc1, 0x48, 0x0c, 0x6f, 0x01, 0x92, 0xc0, 0x30, 0xe5, 0xbd, 0x92, 0xae, 0xd9,
-	0xd8, 0x04, 0xc7, 0x72, 0x2f, 0x50, 0x4e, 0x07, 0x86, 0x27, 0x6b, 0x7a, 0xd2, 0x29, 0x9b, 0xe4,
-	0x37, 0x4a, 0x24, 0xdd, 0xd1, 0xae, 0x4b, 0xcf, 0x90, 0xdc, 0x81, 0xd7, 0x7c, 0xf0, 0xf3, 0x9f,
-	0x7f, 0x07, 0x00, 0x00, 0xff, 0xff, 0xb5, 0x0a, 0xed, 0xcc, 0x27, 0x03, 0x00, 0x00,
+	// 2094 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x58, 0x5b, 0x6f, 0xdb, 0xc8,
+	0x15, 0x0e, 0x75, 0xe7, 0xd1, 0xc5, 0xd4, 0x38, 0xc9, 0x2a, 0x8c, 0xed, 0xf5, 0x72, 0xd3, 0xd8,
+	0x28, 0x0a, 0x37, 0x70, 0x8b, 0x5d, 0xf4, 0x16, 0x40, 0xb1, 0x68, 0x47, 0xb1, 0x2c, 0x69, 0x45,
+	0x39, 0xd9, 0x5e, 0x00, 0x82, 0x96, 0xc6, 0x12, 0x2b, 0x89, 0xd4, 0x72, 0x46, 0x46, 0xfd, 0xd2,
+	0x02, 0x7d, 0x29, 0xfa, 0x43, 0xf6, 0x7f, 0xf4, 0xb7, 0xf4, 0x17, 0xf4, 0xb1, 0x8f, 0xc5, 0x0c,
+	0x87, 0x14, 0x49, 0x51, 0x76, 0xfa, 0x66, 0xcd, 0x39, 0xe7, 0x3b, 0x67, 0xce, 0x8d, 0xdf, 0x18,
+	0x8e, 0x96, 0xb3, 0xc9, 0xcf, 0x97, 0x73, 0xeb, 0x9e, 0xb8, 0x2b, 0x6f, 0x84, 0x23, 0x7f, 0x9a,
+	0x04, 0x7b, 0x77, 0xf6, 0x08, 0x9f, 0x2c, 0x3d, 0x97, 0xba, 0x08, 0xfa, 0xa1, 0x44, 0xfb, 0x06,
+	0x72, 0x86, 0xeb, 0x4c, 0xd0, 0x0e, 0x14, 0x89, 0xeb, 0x4c, 0x4c, 0x7b, 0xdc, 0x90, 0x0e, 0xa5,
+	0xe3, 0x3c, 0xaa, 0x40, 0xce, 0xb1, 0x16, 0xb8, 0x91, 0x39, 0x94, 0x8e, 0x65, 0x26, 0xb6, 0x3c,
+	0x6a, 0x13, 0x4a, 0x1a, 0xd9, 0xc3, 0xec, 0xb1, 0xac, 0x9d, 0x43, 0x7e, 0xe8, 0x59, 0xa3, 0x19,
+	0x2a, 0x43, 0x76, 0xe5, 0xd9, 0xdc, 0x48, 0x7e, 0xc4, 0x08, 0xd5, 0x41, 0x9e, 0x63, 0x67, 0x42,
+	0xa7, 0xe6, 0x82, 0x34, 0x72, 0xcc, 0x8d, 0xf6, 0x6f, 0x09, 0x94, 0xef, 0x56, 0x78, 0x85, 0x59,
+	0x14, 0x03, 0xfc, 0xc3, 0x0a, 0x13, 0x8a, 0x0e, 0x20, 0xc7, 0x82, 0xe1, 0xa0, 0xe5, 0x53, 0xe5,
+	0x64, 0x1d, 0xef, 0x09, 0x0f, 0xf6, 0x18, 0xe4, 0xa9, 0xe5, 0x8c, 0xc9, 0xd4, 0x9a, 0xf9, 0xbe,
+	0xca, 0xa7, 0xcf, 0xa2, 0x4a, 0xef, 0x03, 0x21, 0xfa, 0x15, 0xc8, 0xb6, 0x43, 0xb0, 0x47, 0x6d,
+	0xd7, 0x69, 0x64, 0x0f, 0xa5, 0xe3, 0xda, 0xe9, 0xeb, 0xa8, 0x66, 0xd2, 0xf5, 0x49, 0x3b, 0xd0,
+	0x46, 0x0a, 0x94, 0x96, 0x2e, 0xb1, 0xb9, 0xa5, 0x1f, 0xeb, 0xb7, 0x20, 0xaf, 0xc5, 0x00, 0x85,
+	0x66, 0xbf, 0xaf, 0x77, 0x5b, 0xca, 0x13, 0x54, 0x05, 0xb9, 0xdf, 0x69, 0xfe, 0xde, 0xec, 0xea,
+	0xdf, 0x0f, 0x15, 0x09, 0xed, 0x40, 0xb9, 0x39, 0x34, 0xfb, 0x3d, 0xa3, 0x3d, 0x6c, 0xf7, 0xba,
+	0x4a, 0x46, 0xfb, 0x08, 0xf2, 0x3a, 0x24, 0x04, 0x30, 0x72, 0x1d, 0xea, 0xb9, 0xf3, 0x39, 0xf6,
+	0x44, 0xde, 0x14, 0x28, 0x51, 0x6b, 0x86, 0xdd, 0x3b, 0xec, 0xf1, 0xfb, 0x94, 0x58, 0xee, 0xdc,
+	0x1b, 0x56, 0x36, 0xcc, 0xc3, 0x2e, 0x31, 0x33, 0x82, 0x09, 0xb1, 0x5d, 0x87, 0xd5, 0x88, 0x05,
+	0x24, 0x6b, 0xaf, 0x21, 0xdf, 0xc1, 0x16, 0xc1, 0xa8, 0x06, 0x85, 0xa9, 0x3b, 0x1f, 0x87, 0x78,
+	0x55, 0xc8, 0x53, 0x77, 0x86, 0x1d, 0x0e, 0x96, 0xd3, 0x2e, 0xa1, 0x68, 0xf8, 0xb6, 0x08, 0x20,
+	0x23, 0x4a, 0xcc, 0xeb, 0xe3, 0x61, 0xb2, 0x5a, 0xe0, 0xb1, 0x70, 0xfa, 0x35, 0xcb, 0x96, 0x79,
+	0x3b, 0xb7, 0x27, 0x53, 0xca, 0x4b, 0x96, 0x92, 0x7c, 0xed, 0xc7, 0x2c, 0xd4, 0x23, 0x69, 0x23,
+	0x4b, 0xd7, 0x21, 0x78, 0xb3, 0x7f, 0x6a, 0x50, 0xf8, 0x81, 0x69, 0x05, 0xd8, 0x55, 0xc8, 0xdf,
+	0xba, 0x2b, 0x67, 0x2c, 0xae, 0xa3, 0x40, 0xe9, 0xd6, 0x76, 0x6c, 0x32, 0xc5, 0xfe, 0x65, 0x4a,
+	0xe8, 0x10, 0xf2, 0x94, 0x75, 0x54, 0x23, 0xcf, 0x0b, 0x5a, 0x8f, 0x3a, 0xf6, 0x5b, 0xcd, 0xcf,
+	0xdc, 0xad, 0x3d, 0xc6, 0xce, 0x08, 0x37, 0x0a, 0x87, 0xd2, 0xb1, 0x84, 0x7e, 0x09, 0x05, 0x0f,
+	0x5b, 0xc4, 0x75, 0x1a, 0x45, 0x5e, 0xdd, 0x57, 0x5b, 0xaa, 0xeb, 0x87, 0x79, 0x32, 0xe0, 0xba,
+	0xcc, 0xd7, 0x9c, 0x25, 0xae, 0x51, 0xda, 0xf4, 0xe5, 0x67, 0xf4, 0x15, 0x14, 0x45, 0xba, 0x1b,
+	0x32, 0xd7, 0xd9, 0x8d, 0x25, 0x42, 0x64, 0xb3, 0x06, 0x85, 0x5b, 0xcb, 0x9e, 0xe3, 0x71, 0x03,
+	0x82, 0x4b, 0x62, 0xcf, 0x73, 0xbd, 0x46, 0x39, 0x48, 0x30, 0x99, 0xd9, 0xcb, 0x25, 0x1e, 0x37,
+	0x2a, 0x4c, 0xae, 0xfd, 0x19, 0x0a, 0x22, 0x82, 0x12, 0xe4, 0xba, 0xbd, 0xae, 0xee, 0x37, 0x4f,
+	0xb7, 0x37, 0x34, 0xcf, 0x7b, 0xd7, 0xdd, 0x96, 0x22, 0x21, 0x04, 0xb5, 0x4e, 0xef, 0x93, 0x79,
+	0xd6, 0xeb, 0x9e, 0xb7, 0x5b, 0x7a, 0xf7, 0x4c, 0x57, 0x32, 0xa8, 0x06, 0xf0, 0xdd, 0xb5, 0x7e,
+	0xad, 0x9b, 0xe7, 0xd7, 0x9d, 0x8e, 0x92, 0x45, 0x75, 0xa8, 0xbe, 0x6b, 0x9e, 0x5d, 0xea, 0xdd,
+	0x96, 0xa9, 0x0f, 0x06, 0xbd, 0x81, 0x92, 0x43, 0x0a, 0x54, 0x9a, 0xad, 0x96, 0x39, 0xd0, 0x3f,
+	0xe8, 0x67, 0x43, 0xbd, 0xa5, 0xe4, 0xb5, 0xef, 0x61, 0xc7, 0x98, 0xd9, 0xcb, 0xe8, 0x5c, 0xd5,
+	0xc2, 0x64, 0xf9, 0x0d, 0x50, 0x07, 0xd9, 0xf3, 0x45, 0xa2, 0xef, 0x64, 0xa4, 0x41, 0x09, 0xff,
+	0x65, 0x89, 0x47, 0x14, 0xfb, 0x95, 0x4a, 0xeb, 0x00, 0x1d, 0x94, 0x35, 0x72, 0xa4, 0xfe, 0xe2,
+	0xaa, 0x52, 0x90, 0x8a, 0x3b, 0x97, 0x62, 0xc2, 0x71, 0xf3, 0xcc, 0x15, 0x9d, 0x7a, 0x98, 0xb0,
+	0x36, 0xe5, 0xc0, 0x79, 0xad, 0x06, 0x95, 0xbe, 0xb5, 0x22, 0x58, 0x44, 0xa7, 0xed, 0x40, 0x55,
+	0xfc, 0xf6, 0x31, 0xd9, 0xc1, 0x80, 0xf7, 0x67, 0xa0, 0xa1, 0x40, 0x2d, 0x38, 0x10, 0x2a, 0x55,
+	0x28, 0x1b, 0xd4, 0x5d, 0x06, 0x0a, 0x35, 0xa8, 0xf8, 0x3f, 0x85, 0x58, 0x83, 0xb2, 0x81, 0xf1,
+	0x2c, 0xb8, 0xff, 0x2e, 0x94, 0x83, 0x91, 0x66, 0x1b, 0x48, 0x0a, 0xc2, 0xf0, 0x75, 0x84, 0x4d,
+	0x1d, 0x76, 0xfa, 0x1e, 0xbe, 0xb3, 0xdd, 0x15, 0x09, 0x60, 0x11, 0x28, 0xeb, 0x23, 0xa1, 0x66,
+	0x02, 0xf0, 0xf6, 0xd2, 0x1d, 0xea, 0xdd, 0x3f, 0xba, 0xb1, 0xc2, 0xe6, 0xce, 0x6c, 0x6b, 0xee,
+	0x1d, 0x28, 0xb2, 0x85, 0x6d, 0x3b, 0x13, 0x7f, 0x42, 0x98, 0xd3, 0x8e, 0x4d, 0x28, 0x77, 0x12,
+	0x04, 0xf2, 0x5b, 0xa8, 0x47, 0xce, 0x44, 0xea, 0x8f, 0xa0, 0x88, 0x1d, 0xea, 0xd9, 0x98, 0xdd,
+	0x88, 0xcd, 0xec, 0xf3, 0x8d, 0x19, 0xe0, 0x41, 0x6a, 0x6f, 0xe1, 0xf9, 0x00, 0x2f, 0xdc, 0x3b,
+	0x7c, 0xee, 0xb9, 0x8b, 0x28, 0xee, 0xe6, 0xf4, 0xee, 0x42, 0x99, 0x0f, 0x88, 0x19, 0x5d, 0x23,
+	0x2f, 0xe0, 0x8b, 0x0d, 0x7b, 0x91, 0x8d, 0x0e, 0xa0, 0x2b, 0xf7, 0x0e, 0xb7, 0x9d, 0x87, 0x61,
+	0xa3, 0x3b, 0x35, 0x93, 0xe6, 0x28, 0xcb, 0x1d, 0x3d, 0x83, 0xdd, 0x18, 0x9a, 0x70, 0x72, 0x0c,
+	0xf5, 0xb3, 0x39, 0xb6, 0xbc, 0x98, 0x8f, 0x04, 0x80, 0xc4, 0x01, 0x7e, 0x02, 0x28, 0xaa, 0xb9,
+	0xee, 0x51, 0x8f, 0xc7, 0x2f, 0xc2, 0x61, 0x29, 0xbe, 0xc0, 0xf4, 0xa3, 0x3b, 0x8f, 0xf4, 0xd8,
+	0x29, 0xd4, 0x23, 0x67, 0xc2, 0xb2, 0x06, 0x85, 0x3b, 0x7e, 0x22, 0xee, 0x51, 0x85, 0xfc, 0x62,
+	0x45, 0x83, 0xdd, 0xa6, 0x69, 0xa0, 0x18, 0x09, 0x9c, 0xa4, 0x89, 0xb6, 0x0b, 0x75, 0x23, 0x89,
+	0xab, 0x7d, 0x09, 0x35, 0x03, 0xd3, 0xab, 0x15, 0x0d, 0xcd, 0x42, 0x64, 0x3e, 0x45, 0xac, 0x19,
+	0x43, 0x05, 0x61, 0xf3, 0x0f, 0x09, 0xea, 0x1f, 0x5d, 0x8a, 0xe3, 0xee, 0xc4, 0xb8, 0x05, 0x1f,
+	0x80, 0x5f, 0x83, 0x3c, 0xb6, 0x3d, 0x3c, 0x0a, 0x33, 0x5d, 0x3b, 0x3d, 0x8a, 0x76, 0xc5, 0x06,
+	0xc0, 0x49, 0x2b, 0x50, 0xd7, 0x8e, 0x40, 0x0e, 0x7f, 0x44, 0xf6, 0x54, 0x01, 0x32, 0xd7, 0x7d,
+	0x45, 0x62, 0x27, 0xad, 0xde, 0x27, 0xf6, 0x59, 0xfb, 0x04, 0x28, 0x8a, 0xb3, 0x25, 0x57, 0x3b,
+	0x50, 0x1c, 0x4d, 0x2d, 0x67, 0x12, 0xfd, 0x12, 0xf8, 0x9b, 0x21, 0xbb, 0xb9, 0x19, 0xfc, 0x0f,
+	0x6d, 0x0f, 0x14, 0x16, 0xeb, 0x8d, 0x35, 0x9a, 0x19, 0x98, 0x52, 0xdb, 0x99, 0x10, 0xf4, 0x14,
+	0x2a, 0x23, 0xcf, 0x25, 0xe4, 0xd6, 0x1a, 0xe3, 0x70, 0x78, 0x19, 0xf8, 0xc4, 0x5a, 0xce, 0x31,
+	0x21, 0x02, 0xfc, 0x29, 0x54, 0xd8, 0x1e, 0x32, 0x03, 0x35, 0x7f, 0xd5, 0xec, 0x81, 0x7a, 0x81,
+	0x69, 0x12, 0x33, 0x28, 0xf9, 0x15, 0xbc, 0x4c, 0x95, 0x8a, 0x0b, 0x9d, 0x40, 0x89, 0x88, 0x33,
+	0x31, 0xdf, 0x7b, 0xd1, 0x54, 0x26, 0xed, 0xb4, 0x0e, 0xa8, 0xc6, 0x56, 0x67, 0xff, 0x37, 0xda,
+	0x3e, 0xbc, 0x34, 0xb6, 0x07, 0xc7, 0xda, 0x4a, 0xc4, 0x6e, 0x87, 0x7b, 0x5e, 0xfb, 0xbb, 0x04,
+	0x28, 0x7a, 0x2a, 0x2e, 0xf2, 0xd8, 0x92, 0x7a, 0x05, 0x79, 0x42, 0x2d, 0x8a, 0x45, 0xc3, 0x3c,
+	0x4b, 0xc6, 0x65, 0x30, 0x61, 0x72, 0x89, 0x86, 0x45, 0x4c, 0x32, 0xbb, 0x0f, 0xf0, 0x4c, 0xc4,
+	0xf0, 0xde, 0x26, 0xd4, 0xf5, 0xee, 0x83, 0x0c, 0x28, 0x50, 0x22, 0xb6, 0x33, 0x0a, 0xab, 0x98,
+	0x65, 0x27, 0x2b, 0x87, 0xda, 0x73, 0x76, 0x92, 0xe1, 0x27, 0x55, 0xc8, 0xcf, 0xed, 0x85, 0x4d,
+	0x45, 0xfd, 0xfe, 0x06, 0xcf, 0x93, 0x58, 0x9f, 0x79, 0x27, 0x46, 0x9b, 0xa8, 0xe5, 0x51, 0x3c,
+	0x5e, 0x83, 0xef, 0x42, 0x39, 0xe0, 0x1e, 0xc1, 0x0d, 0xb2, 0x01, 0x8f, 0xcd, 0x25, 0x3f, 0xdc,
+	0x79, 0x3e, 0x87, 0x7f, 0x85, 0xaa, 0x81, 0x2d, 0x6f, 0x34, 0x0d, 0x2e, 0x81, 0x00, 0xf8, 0x42,
+	0x37, 0x39, 0xdf, 0x95, 0x92, 0x7c, 0x37, 0xc3, 0xf9, 0x6e, 0x15, 0xf2, 0x13, 0xec, 0x78, 0x3e,
+	0x85, 0xe3, 0x3f, 0xad, 0xf9, 0xcd, 0x6a, 0x21, 0x9c, 0x94, 0x21, 0x6b, 0x39, 0xf7, 0xdc, 0x81,
+	0xcc, 0xa6, 0xc6, 0xbd, 0xbd, 0x25, 0x98, 0x36, 0x0a, 0xc1, 0x86, 0xf1, 0x13, 0x50, 0xe4, 0x09,
+	0x78, 0x07, 0xb5, 0xc0, 0xbf, 0xb8, 0xf8, 0x57, 0x50, 0xe0, 0x01, 0x04, 0x4b, 0x3f, 0xe5, 0x93,
+	0xc2, 0x59, 0x20, 0xb5, 0xe6, 0xfe, 0xaa, 0xd5, 0x7e, 0x07, 0x4f, 0x3f, 0x59, 0x74, 0x34, 0x0d,
+	0x7a, 0x29, 0xb8, 0xca, 0x73, 0xa8, 0x59, 0xb7, 0x14, 0x7b, 0x26, 0x61, 0x07, 0xce, 0xc8, 0xbf,
+	0x4e, 0x8e, 0x99, 0xe3, 0xa5, 0x3b, 0x9a, 0xfa, 0xcc, 0x40, 0xfb, 0x4f, 0x16, 0xaa, 0x81, 0xa9,
+	0x7e, 0x87, 0x1d, 0xbf, 0x90, 0x71, 0x93, 0xa7, 0x50, 0xa1, 0xf6, 0x02, 0x13, 0x6a, 0x2d, 0x96,
+	0xeb, 0x7c, 0xff, 0x0c, 0x72, 0xf4, 0x7e, 0x89, 0x05, 0xff, 0x3e, 0x48, 0x6b, 0x77, 0x0e, 0x78,
+	0x32, 0xbc, 0x5f, 0xae, 0x2b, 0x9a, 0x7b, 0xec, 0x53, 0xba, 0x95, 0x27, 0xae, 0x37, 0x50, 0x21,
+	0xbe, 0xad, 0x8b, 0x71, 0x92, 0x56, 0x0a, 0x32, 0x2f, 0x48, 0x91, 0xbc, 0x49, 0x8a, 0x80, 0x1f,
+	0x85, 0x83, 0x51, 0x7e, 0x68, 0x30, 0xc2, 0x7c, 0x55, 0x78, 0xbe, 0xfe, 0x25, 0x41, 0x8e, 0x5f,
+	0xa8, 0x0c, 0xc5, 0xeb, 0xee, 0x65, 0x97, 0xed, 0xcc, 0x27, 0x8c, 0xba, 0x0d, 0x07, 0xcd, 0xb3,
+	0x4b, 0xd3, 0x18, 0x36, 0x07, 0x43, 0x5d, 0x30, 0x3e, 0xff, 0xe8, 0xbc, 0xdd, 0x6d, 0x1b, 0xef,
+	0xf5, 0x96, 0x92, 0x61, 0x36, 0xc6, 0x65, 0xbb, 0xdf, 0xd7, 0x5b, 0x4a, 0x96, 0x3d, 0x35, 0xfa,
+	0xcd, 0x6b, 0x43, 0x6f, 0x29, 0x39, 0x26, 0x18, 0xe8, 0xc6, 0xf5, 0x15, 0xa3, 0x78, 0xcc, 0xf2,
+	0x63, 0xaf, 0x73, 0x7d, 0xa5, 0x9b, 0x67, 0xef, 0x9b, 0xdd, 0x0b, 0xbd, 0xa5, 0x14, 0x98, 0x03,
+	0x9f, 0x2b, 0x06, 0x47, 0x45, 0xd4, 0x80, 0xa7, 0x01, 0x5d, 0x6c, 0xb5, 0x8d, 0xb3, 0x5e, 0xb7,
+	0xeb, 0x73, 0x44, 0xc6, 0xc2, 0x2b, 0xc2, 0x75, 0xb3, 0xdd, 0xd1, 0x5b, 0x8a, 0xcc, 0x1d, 0x0f,
+	0x7b, 0xdc, 0x31, 0x68, 0xff, 0x94, 0xa0, 0x70, 0x6e, 0xad, 0xe6, 0x94, 0xa0, 0x17, 0x50, 0xe7,
+	0xf4, 0xdd, 0x5c, 0x7a, 0xee, 0x8d, 0x75, 0x63, 0xcf, 0x6d, 0x7a, 0xcf, 0x8b, 0x2e, 0xa1, 0x97,
+	0xb0, 0xcb, 0x48, 0xf0, 0xca, 0xc3, 0x31, 0x61, 0x86, 0x0b, 0x1b, 0xa0, 0x30, 0xa1, 0xed, 0x4c,
+	0x4c, 0x41, 0x05, 0xfc, 0xc7, 0x20, 0x0b, 0x1e, 0xe6, 0x16, 0xc5, 0xce, 0xe8, 0x3e, 0xdc, 0x19,
+	0xcc, 0x0b, 0x2f, 0x4d, 0x0c, 0x88, 0xd5, 0x59, 0x12, 0xdf, 0x6a, 0x3f, 0x9a, 0x60, 0xcf, 0x7d,
+	0x0b, 0xf5, 0xc8, 0x99, 0x18, 0x0c, 0x8d, 0x71, 0x72, 0x76, 0x22, 0x76, 0x02, 0x8a, 0x56, 0xcb,
+	0xd7, 0xd5, 0xbe, 0xe1, 0x1f, 0xec, 0x18, 0xd8, 0x67, 0xd9, 0xf9, 0x1f, 0xf1, 0xb8, 0xc3, 0x9f,
+	0xbe, 0x05, 0x79, 0xdd, 0x04, 0xb1, 0x62, 0x97, 0xa1, 0xc8, 0xde, 0x85, 0xed, 0xee, 0x85, 0x22,
+	0x45, 0xaa, 0x98, 0x89, 0x66, 0x39, 0x7b, 0xfa, 0xdf, 0x0a, 0x44, 0x5e, 0xe4, 0xa8, 0x0b, 0x72,
+	0xf8, 0x6e, 0x41, 0x7b, 0x0f, 0x3d, 0x56, 0xd5, 0xfd, 0x07, 0x1f, 0x3b, 0xda, 0x93, 0x63, 0xe9,
+	0x8d, 0x84, 0x2e, 0xa0, 0x14, 0xb0, 0x75, 0xf4, 0x32, 0x36, 0x4d, 0xf1, 0xd7, 0x81, 0xba, 0x97,
+	0x2e, 0x0c, 0xc0, 0xd0, 0x5b, 0xc8, 0x73, 0x7e, 0x8e, 0x1a, 0xb1, 0xfe, 0x8f, 0x50, 0x78, 0xf5,
+	0x45, 0x8a, 0x24, 0xb4, 0x6f, 0x42, 0xc1, 0x67, 0xef, 0x28, 0xa6, 0x16, 0xa3, 0xf8, 0xaa, 0x9a,
+	0x26, 0x0a, 0x21, 0x7e, 0x03, 0x39, 0xc6, 0xef, 0xd1, 0x17, 0xb1, 0x50, 0xd7, 0x0f, 0x00, 0xb5,
+	0xb1, 0x29, 0x88, 0x19, 0x63, 0x3c, 0x4b, 0x18, 0xaf, 0x9f, 0x07, 0x6a, 0x63, 0x53, 0x10, 0x1a,
+	0x5f, 0x40, 0x29, 0x78, 0x02, 0xc4, 0xb3, 0x98, 0x78, 0x2b, 0xa8, 0x7b, 0xe9, 0xc2, 0x10, 0xe8,
+	0x03, 0xc8, 0x21, 0x85, 0x8f, 0x97, 0x37, 0xc9, 0xf6, 0xd5, 0xfd, 0x2d, 0xd2, 0x10, 0xeb, 0x4f,
+	0xb0, 0x93, 0x20, 0xe4, 0x48, 0x8b, 0xe7, 0x2f, 0x8d, 0xed, 0xab, 0x5f, 0x3f, 0xa8, 0x13, 0xa2,
+	0xf7, 0xa1, 0x1c, 0x61, 0xe1, 0x28, 0xb6, 0xb7, 0x37, 0xc9, 0xbe, 0xfa, 0xe5, 0x56, 0x79, 0x88,
+	0x78, 0x05, 0xb0, 0xa6, 0xe5, 0x28, 0x76, 0xbd, 0x0d, 0x62, 0xaf, 0x1e, 0x6c, 0x13, 0x47, 0x53,
+	0x19, 0x52, 0xf5, 0x78, 0x2a, 0x93, 0xac, 0x5e, 0xdd, 0xdf, 0x22, 0x8d, 0x62, 0x19, 0xe9, 0x58,
+	0xc6, 0x83, 0x58, 0x46, 0x0a, 0x56, 0x0b, 0x8a, 0x82, 0xb4, 0x23, 0x35, 0xa1, 0x1b, 0xa1, 0xfa,
+	0xea, 0xcb, 0x54, 0x59, 0x34, 0x59, 0x6b, 0x76, 0x1d, 0x4f, 0xd6, 0x06, 0x7b, 0x57, 0x0f, 0xb6,
+	0x89, 0x43, 0xb8, 0x29, 0xec, 0xa6, 0x90, 0x5c, 0xf4, 0x3a, 0x91, 0x98, 0x2d, 0xb4, 0x55, 0x3d,
+	0x7a, 0x54, 0x2f, 0xea, 0xc9, 0x78, 0xcc, 0x93, 0xf1, 0x99, 0x9e, 0x8c, 0x07, 0x3d, 0x5d, 0x01,
+	0xac, 0x69, 0x2e, 0xda, 0x4f, 0x09, 0x71, 0x4d, 0x8a, 0xd5, 0x83, 0x6d, 0xe2, 0xe8, 0x82, 0xf2,
+	0x49, 0x56, 0x7c, 0x41, 0xc5, 0x88, 0x9f, 0xaa, 0xa6, 0x89, 0x22, 0x33, 0x53, 0x8d, 0x71, 0x2c,
+	0x74, 0x18, 0x55, 0x4f, 0xa3, 0x5f, 0x89, 0x9d, 0x19, 0xe5, 0x43, 0xda, 0x93, 0x37, 0x12, 0xfa,
+	0x23, 0xd4, 0xe2, 0xd4, 0x17, 0x7d, 0x95, 0x72, 0x91, 0x38, 0xc5, 0x56, 0xb5, 0x87, 0x54, 0x82,
+	0x60, 0xdf, 0x48, 0xa7, 0x3f, 0x4a, 0x50, 0x1e, 0x62, 0x42, 0xcf, 0xfc, 0x7f, 0x48, 0x8a, 0x89,
+	0x12, 0x9f, 0xfc, 0xe4, 0x44, 0xc5, 0x3e, 0x97, 0xea, 0xfe, 0x16, 0x69, 0x62, 0xa2, 0xd2, 0xb0,
+	0x8c, 0x07, 0xb1, 0x8c, 0x4d, 0xac, 0x77, 0x95, 0x3f, 0xc0, 0xfa, 0xbf, 0xd9, 0x37, 0x05, 0xfe,
+	0x6f, 0xec, 0x5f, 0xfc, 0x6f, 0x00, 0x55, 0x30, 0x44, 0x67, 0xf1, 0x16, 0x00, 0x00,
 }
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time, and waits for it to pass. Servers use RealClock
+// unless configured otherwise, while tests can use a FakeClock to move
+// time along themselves.
+type Clock interface {
+	Now() time.Time
+
+	// After sends the time on the returned channel once d has passed.
+	After(d time.Duration) <-chan time.Time
+
+	// NewTimer is like After, but the timer can be stopped, for
+	// callers that may stop waiting before it fires.
+	NewTimer(d time.Duration) Timer
+
+	Sleep(d time.Duration)
+}
+
+// Timer is a Clock's time.Timer.
+type Timer interface {
+	// C receives the time once the timer fires.
+	C() <-chan time.Time
+
+	// Stop stops the timer, returning false if it had already fired.
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.timer.C }
+func (t realTimer) Stop() bool          { return t.timer.Stop() }
+
+// RealClock is the system clock.
+var RealClock Clock = realClock{}
+
+// FakeClock is a Clock whose time only passes when Advance is called.
+type FakeClock struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is waiting on a FakeClock, until it fires or is stopped.
+type fakeWaiter struct {
+	clock *FakeClock
+	at    time.Time
+	c     chan time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	f := &FakeClock{now: now}
+	f.cond = sync.NewCond(&f.lock)
+	return f
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.now
+}
+
+// After's waiters are only done waiting once they fire, so callers
+// that may stop waiting first should use NewTimer, and stop it.
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+func (f *FakeClock) NewTimer(d time.Duration) Timer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	w := &fakeWaiter{clock: f, at: f.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.c <- f.now
+		return w
+	}
+
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+	return w
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (w *fakeWaiter) Stop() bool {
+	f := w.clock
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for i, waiting := range f.waiters {
+		if waiting == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *FakeClock) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// Advance moves time forward by d, waking everything that was
+// waiting for it to pass.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.now = f.now.Add(d)
+
+	var waiting []*fakeWaiter
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			waiting = append(waiting, w)
+			continue
+		}
+
+		w.c <- f.now
+	}
+	f.waiters = waiting
+}
+
+// BlockUntil blocks until at least n callers are waiting on the clock,
+// not counting those whose timers have fired or been stopped.
+// Waiting for something to wait on the clock before advancing it keeps
+// tests from racing with the code under test.
+func (f *FakeClock) BlockUntil(n int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClockTimers(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+
+	stopped := clock.NewTimer(time.Second)
+	fired := clock.After(2 * time.Second)
+	assert.Len(t, clock.waiters, 2)
+
+	// Stopped timers are no longer waiting, and never fire.
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+	assert.Len(t, clock.waiters, 1)
+
+	// Neither are timers once they've fired.
+	clock.Advance(2 * time.Second)
+	assert.Empty(t, clock.waiters)
+	assert.Len(t, fired, 1)
+	assert.Len(t, stopped.C(), 0)
+
+	// Timers for no time at all fire straight away.
+	assert.Len(t, clock.After(0), 1)
+	assert.Empty(t, clock.waiters)
+}
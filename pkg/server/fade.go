@@ -0,0 +1,291 @@
+package server
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+const (
+	// Fades change the volume in steps of fadeStep.
+	fadeStep = 50 * time.Millisecond
+
+	// How often the crossfader checks whether a song is ending.
+	crossfadePollInterval = 250 * time.Millisecond
+
+	maxCrossfade = 30 * time.Second
+	maxSkipFade  = 10 * time.Second
+)
+
+// PlaybackConfig configures transitions between songs.
+type PlaybackConfig struct {
+	Crossfade time.Duration
+	SkipFade  time.Duration
+}
+
+func validatePlaybackSettings(settings *playsource.PlaybackSettings) error {
+	if settings == nil {
+		return errf(codes.InvalidArgument, "Settings must be specified")
+	}
+
+	if settings.CrossfadeMs < 0 || time.Duration(settings.CrossfadeMs)*time.Millisecond > maxCrossfade {
+		return errf(codes.InvalidArgument, "Crossfade must be between 0 and %v", maxCrossfade)
+	}
+
+	if settings.SkipFadeMs < 0 || time.Duration(settings.SkipFadeMs)*time.Millisecond > maxSkipFade {
+		return errf(codes.InvalidArgument, "Skip fade must be between 0 and %v", maxSkipFade)
+	}
+
+	return nil
+}
+
+// playbackSettings holds the settings that can be changed at runtime.
+type playbackSettings struct {
+	lock     sync.Mutex
+	settings playsource.PlaybackSettings
+}
+
+func newPlaybackSettings(config PlaybackConfig) *playbackSettings {
+	return &playbackSettings{
+		settings: playsource.PlaybackSettings{
+			CrossfadeMs: int32(config.Crossfade / time.Millisecond),
+			Gapless:     true,
+			SkipFadeMs:  int32(config.SkipFade / time.Millisecond),
+		},
+	}
+}
+
+func (p *playbackSettings) get() playsource.PlaybackSettings {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.settings
+}
+
+func (p *playbackSettings) set(settings playsource.PlaybackSettings) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.settings = settings
+}
+
+func (p *playbackSettings) crossfade() time.Duration {
+	return time.Duration(p.get().CrossfadeMs) * time.Millisecond
+}
+
+func (p *playbackSettings) skipFade() time.Duration {
+	return time.Duration(p.get().SkipFadeMs) * time.Millisecond
+}
+
+// fader ramps the volume up or down. Fades scale the volume listeners
+// asked for, the target, rather than replacing it, so that the target
+// can still be changed while fading, and is what fades end up at.
+type fader struct {
+	clock Clock
+
+	// The target is only remembered while fading. Otherwise, it's
+	// whatever the mixer says. The gain is the percentage of the
+	// target that's audible.
+	lock   sync.Mutex
+	target int
+	gain   int
+
+	getMixer func(context.Context) (int, error)
+	setMixer func(context.Context, int) error
+}
+
+func newFader(clock Clock, getMixer func(context.Context) (int, error), setMixer func(context.Context, int) error) *fader {
+	return &fader{
+		clock:    clock,
+		gain:     100,
+		getMixer: getMixer,
+		setMixer: setMixer,
+	}
+}
+
+// getVolume returns the target volume, which isn't what's audible
+// while fading.
+func (f *fader) getVolume(ctx context.Context) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.gain < 100 {
+		return f.target, nil
+	}
+
+	return f.getMixer(ctx)
+}
+
+// setVolume sets the target volume. While fading, the mixer is set to
+// as much of it as the fade has got to.
+func (f *fader) setVolume(ctx context.Context, volume int) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.target = volume
+	return f.setMixer(ctx, volume*f.gain/100)
+}
+
+// fade ramps the gain from where it is to gain, over d. If stop is
+// closed, the fade stops where it's got to.
+func (f *fader) fade(ctx context.Context, gain int, d time.Duration, stop <-chan struct{}) error {
+	f.lock.Lock()
+	from := f.gain
+	f.lock.Unlock()
+
+	steps := int(d / fadeStep)
+	for i := 1; i < steps; i++ {
+		if err := f.step(ctx, from+(gain-from)*i/steps); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return nil
+		case <-f.clock.After(fadeStep):
+		}
+	}
+
+	return f.step(ctx, gain)
+}
+
+// step sets the gain, and the mixer to match. The lock is only held
+// for a step at a time, so the target can be changed mid-fade.
+func (f *fader) step(ctx context.Context, gain int) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.gain == 100 {
+		target, err := f.getMixer(ctx)
+		if err != nil {
+			return err
+		}
+		f.target = target
+	}
+
+	f.gain = gain
+	return f.setMixer(ctx, f.target*gain/100)
+}
+
+// around fades the volume out over d, runs fn, and then restores the
+// volume. Fading is best effort, so fn runs even if fading fails, or is
+// stopped early by closing stop.
+func (f *fader) around(ctx context.Context, d time.Duration, stop <-chan struct{}, fn func() error) error {
+	if d <= 0 {
+		return fn()
+	}
+
+	if err := f.fade(ctx, 0, d, stop); err != nil {
+		log.Println("Error fading out:", err)
+	}
+
+	err := fn()
+	if restoreErr := f.step(ctx, 100); restoreErr != nil {
+		log.Println("Error restoring volume:", restoreErr)
+	}
+
+	return err
+}
+
+// crossfade fades the end of each song out, and the start of the next
+// one in, for as long as the crossfade setting is set, until ctx is done.
+func crossfade(ctx context.Context, client *mopidy.Client, settings *playbackSettings, f *fader, clock Clock) {
+	// The track we faded out.
+	var fadedOut int
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(crossfadePollInterval):
+		}
+
+		d := settings.crossfade()
+		if d == 0 && fadedOut == 0 {
+			continue
+		}
+
+		var state mopidy.PlayState
+		var current *mopidy.TlTrack
+		var position int
+
+		var batch mopidy.Batch
+		batch.CurrentState(&state)
+		batch.CurrentTlTrack(&current)
+		batch.TimePosition(&position)
+		if err := client.Batch(ctx, &batch); err != nil {
+			continue
+		}
+
+		if fadedOut != 0 {
+			if current != nil && current.TLID == fadedOut && state == mopidy.Playing {
+				continue
+			}
+
+			// If the next song has started, fade it in. Otherwise, playback
+			// stopped before it could, so the volume is restored as is.
+			fadeIn := d
+			if current == nil || state != mopidy.Playing {
+				fadeIn = 0
+			}
+
+			if err := f.fade(ctx, 100, fadeIn, nil); err != nil {
+				log.Println("Error restoring volume:", err)
+			}
+
+			fadedOut = 0
+			continue
+		}
+
+		if current == nil || state != mopidy.Playing || current.Track.Length == 0 {
+			continue
+		}
+
+		remaining := time.Duration(current.Track.Length-position) * time.Millisecond
+		if remaining > d {
+			continue
+		}
+
+		fadedOut = current.TLID
+		if err := f.fade(ctx, 0, remaining, nil); err != nil {
+			log.Println("Error fading out:", err)
+		}
+	}
+}
+
+// trackEnded returns a channel that's closed once tlid is no longer the
+// current track, which is checked every crossfadePollInterval until ctx
+// is done.
+func trackEnded(ctx context.Context, client *mopidy.Client, clock Clock, tlid int) <-chan struct{} {
+	ended := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-clock.After(crossfadePollInterval):
+			}
+
+			current, err := client.CurrentTlTrack(ctx)
+			if err != nil {
+				continue
+			}
+
+			if current == nil || current.TLID != tlid {
+				close(ended)
+				return
+			}
+		}
+	}()
+
+	return ended
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestFaderAround(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	volume := 80
+	var volumes []int
+	f := newFader(
+		clock,
+		func(context.Context) (int, error) { return volume, nil },
+		func(ctx context.Context, v int) error {
+			volume = v
+			volumes = append(volumes, v)
+			return nil
+		},
+	)
+
+	var skippedAt int
+	done := make(chan error)
+	go func() {
+		done <- f.around(context.Background(), 4*fadeStep, nil, func() error {
+			skippedAt = volume
+			return errors.New("skip failed")
+		})
+	}()
+
+	// Each step waits on the clock before the next.
+	for i := 0; i < 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(fadeStep)
+	}
+	err := <-done
+
+	// The volume ramps down, the skip happens in silence, and the
+	// volume is restored, even though the skip failed.
+	assert.EqualError(t, err, "skip failed")
+	assert.Equal(t, 0, skippedAt)
+	assert.Equal(t, []int{60, 40, 20, 0, 80}, volumes)
+
+	// Without a duration, there's no fading at all.
+	volumes = nil
+	assert.NoError(t, f.around(context.Background(), 0, nil, func() error { return nil }))
+	assert.Empty(t, volumes)
+}
+
+func TestFaderTarget(t *testing.T) {
+	mixer := 80
+	f := newFader(
+		NewFakeClock(time.Now()),
+		func(context.Context) (int, error) { return mixer, nil },
+		func(ctx context.Context, v int) error {
+			mixer = v
+			return nil
+		},
+	)
+	ctx := context.Background()
+
+	// Halfway through a fade, the target is still what was asked for.
+	assert.NoError(t, f.step(ctx, 50))
+	assert.Equal(t, 40, mixer)
+	volume, err := f.getVolume(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 80, volume)
+
+	// Changing it mid-fade scales the new target instead, and the fade
+	// ends up at it.
+	assert.NoError(t, f.setVolume(ctx, 60))
+	assert.Equal(t, 30, mixer)
+	volume, err = f.getVolume(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 60, volume)
+
+	assert.NoError(t, f.step(ctx, 100))
+	assert.Equal(t, 60, mixer)
+
+	// Once the fade is over, the mixer is the target again.
+	mixer = 20
+	volume, err = f.getVolume(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, volume)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+func validateFaults(f *playsource.Faults) error {
+	if f == nil {
+		return errf(codes.InvalidArgument, "Faults must be specified")
+	}
+
+	for _, p := range []float64{f.FoundProbability, f.FailureProbability, f.ErrorProbability} {
+		if p < 0 || p > 1 {
+			return errf(codes.InvalidArgument, "Probabilities must be between 0 and 1")
+		}
+	}
+
+	if f.LatencyMs < 0 {
+		return errf(codes.InvalidArgument, "Latency must not be negative")
+	}
+
+	return nil
+}
+
+// faults holds the faults a TestServer injects, which can be
+// changed while it runs.
+type faults struct {
+	clock Clock
+
+	lock   sync.Mutex
+	faults playsource.Faults
+}
+
+func newFaults(f playsource.Faults, clock Clock) *faults {
+	return &faults{clock: clock, faults: f}
+}
+
+func (f *faults) get() playsource.Faults {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.faults
+}
+
+func (f *faults) set(faults playsource.Faults) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.faults = faults
+}
+
+// inject waits out the latency, and then fails as often as
+// the error probability says to.
+func (f *faults) inject() error {
+	faults := f.get()
+	f.clock.Sleep(time.Duration(faults.LatencyMs) * time.Millisecond)
+
+	if rand.Float64() < faults.ErrorProbability {
+		return errf(codes.Unavailable, "Simulated playback system outage")
+	}
+
+	return nil
+}
+
+// found returns whether a song should be found.
+func (f *faults) found() bool {
+	return rand.Float64() < f.get().FoundProbability
+}
+
+// fails returns whether song should fail to play.
+func (f *faults) fails(song playsource.Song) bool {
+	faults := f.get()
+	for _, id := range faults.FailingSongIds {
+		if id == song.SongId {
+			return true
+		}
+	}
+
+	return rand.Float64() < faults.FailureProbability
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/history"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+func toMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func fromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// sendHistory streams the entries of store that match req.
+func sendHistory(store history.Store, req *playsource.GetPlayHistoryRequest, stream playsource.Playsource_GetPlayHistoryServer) error {
+	entries, err := store.Query(history.Query{
+		Since: fromMillis(req.SinceMs),
+		Until: fromMillis(req.UntilMs),
+		Limit: int(req.Limit),
+	})
+	if err != nil {
+		return errf(codes.Internal, err.Error())
+	}
+
+	for i := range entries {
+		resp := &playsource.GetPlayHistoryResponse{
+			Song:       &entries[i].Song,
+			StartedMs:  toMillis(entries[i].Started),
+			FinishedMs: toMillis(entries[i].Finished),
+			Uri:        entries[i].URI,
+			Skipped:    entries[i].Skipped,
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
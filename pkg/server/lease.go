@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// ErrLeaseLost is returned when using a lease that has since been
+// taken over by another controller.
+var ErrLeaseLost = errors.New("lease lost")
+
+// DefaultLeaseTimeout is how long a silent primary keeps its lease
+// for, unless servers are configured otherwise.
+const DefaultLeaseTimeout = 30 * time.Second
+
+// LeaseManager hands out the primary lease. Each time the lease changes
+// hands, its fencing token increases, so a primary that has been taken
+// over can't keep acting on its stale lease.
+type LeaseManager struct {
+	timeout time.Duration
+	clock   Clock
+
+	lock          sync.Mutex
+	holder        string
+	token         uint64
+	held          bool
+	lastHeartbeat time.Time
+
+	// Closed when the current lease is taken over.
+	revoked chan struct{}
+}
+
+// NewLeaseManager returns a LeaseManager whose leases expire after timeout
+// without a heartbeat. Without a positive timeout, leases would expire as
+// soon as they're acquired, so DefaultLeaseTimeout is used instead.
+func NewLeaseManager(timeout time.Duration, clock Clock) *LeaseManager {
+	if timeout <= 0 {
+		timeout = DefaultLeaseTimeout
+	}
+
+	return &LeaseManager{timeout: timeout, clock: clock}
+}
+
+// Acquire acquires the lease for holder. If the lease is held by a
+// controller that is still heartbeating, Acquire fails unless takeover
+// is set. The returned channel is closed if the lease is taken over.
+func (l *LeaseManager) Acquire(holder string, takeover bool) (token uint64, revoked <-chan struct{}, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.clock.Now()
+	if l.held && !takeover && now.Sub(l.lastHeartbeat) < l.timeout {
+		return 0, nil, fmt.Errorf("lease held by %q", l.holder)
+	}
+
+	if l.held {
+		close(l.revoked)
+	}
+
+	l.token++
+	l.holder = holder
+	l.held = true
+	l.lastHeartbeat = now
+	l.revoked = make(chan struct{})
+
+	return l.token, l.revoked, nil
+}
+
+// Heartbeat keeps the lease identified by token alive.
+func (l *LeaseManager) Heartbeat(token uint64) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.held || l.token != token {
+		return ErrLeaseLost
+	}
+
+	l.lastHeartbeat = l.clock.Now()
+	return nil
+}
+
+// Check returns ErrLeaseLost if token no longer identifies the lease.
+func (l *LeaseManager) Check(token uint64) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.held || l.token != token {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// Release gives up the lease identified by token, if it's still held.
+func (l *LeaseManager) Release(token uint64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.held && l.token == token {
+		l.held = false
+		l.holder = ""
+	}
+}
+
+// Holder returns the name of the primary, if any.
+func (l *LeaseManager) Holder() (holder string, ok bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.holder, l.held
+}
+
+// checkPrimary returns an error unless token identifies the lease,
+// for RPCs only the primary may make.
+func checkPrimary(lease *LeaseManager, token uint64) error {
+	if err := lease.Check(token); err != nil {
+		return errf(codes.PermissionDenied, "Only the primary may change the queue")
+	}
+
+	return nil
+}
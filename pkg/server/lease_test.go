@@ -0,0 +1,102 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// isClosed returns whether c is closed.
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	l := NewLeaseManager(time.Minute, clock)
+
+	token, revoked, err := l.Acquire("a", false)
+	require.NoError(t, err)
+
+	// A live primary keeps the lease, as long as it heartbeats.
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Minute - time.Second)
+		_, _, err = l.Acquire("b", false)
+		assert.Error(t, err)
+		assert.NoError(t, l.Heartbeat(token))
+	}
+
+	holder, ok := l.Holder()
+	assert.True(t, ok)
+	assert.Equal(t, "a", holder)
+
+	// Once it's silent for the timeout, anyone can take it.
+	clock.Advance(time.Minute)
+	_, _, err = l.Acquire("b", false)
+	assert.NoError(t, err)
+	assert.True(t, isClosed(revoked))
+	assert.Equal(t, ErrLeaseLost, l.Heartbeat(token))
+}
+
+func TestLeaseDefaultTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		clock := NewFakeClock(time.Now())
+		l := NewLeaseManager(timeout, clock)
+
+		_, _, err := l.Acquire("a", false)
+		require.NoError(t, err)
+
+		// Without a timeout, the lease can't be stolen right away.
+		_, _, err = l.Acquire("b", false)
+		assert.Error(t, err, "timeout %v", timeout)
+
+		clock.Advance(DefaultLeaseTimeout)
+		_, _, err = l.Acquire("b", false)
+		assert.NoError(t, err, "timeout %v", timeout)
+	}
+}
+
+func TestLeaseFencing(t *testing.T) {
+	l := NewLeaseManager(time.Minute, NewFakeClock(time.Now()))
+
+	stale, revoked, err := l.Acquire("a", false)
+	require.NoError(t, err)
+
+	// Taking over demotes the primary to an observer. It finds out through
+	// the revoked channel, or when it next uses its token, which no longer
+	// lets it do what only the primary may.
+	token, _, err := l.Acquire("b", true)
+	require.NoError(t, err)
+	assert.True(t, token > stale)
+	assert.True(t, isClosed(revoked))
+
+	assert.Equal(t, ErrLeaseLost, l.Check(stale))
+	assert.Equal(t, ErrLeaseLost, l.Heartbeat(stale))
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(checkPrimary(l, stale)))
+	assert.NoError(t, checkPrimary(l, token))
+
+	// Stale tokens can't release the lease from its new holder.
+	l.Release(stale)
+	holder, ok := l.Holder()
+	assert.True(t, ok)
+	assert.Equal(t, "b", holder)
+
+	// Once released, it's free, and the old token stays stale.
+	l.Release(token)
+	_, ok = l.Holder()
+	assert.False(t, ok)
+	assert.Equal(t, ErrLeaseLost, l.Check(token))
+
+	next, _, err := l.Acquire("a", false)
+	require.NoError(t, err)
+	assert.True(t, next > token)
+}
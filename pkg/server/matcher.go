@@ -0,0 +1,233 @@
+package server
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+// Matcher picks the track that best matches a song out of a set
+// of search results. If no candidate is good enough, ok is false.
+type Matcher interface {
+	Match(song playsource.Song, candidates []mopidy.Track) (track mopidy.Track, confidence float64, ok bool)
+}
+
+// DefaultVariants are words that indicate a track is a different
+// version of a song than what was likely requested.
+var DefaultVariants = []string{
+	"remix",
+	"live",
+	"karaoke",
+	"instrumental",
+	"acoustic",
+	"cover",
+	"tribute",
+}
+
+// ScoringMatcher scores candidates by how similar their title and
+// artists are to the song, and picks the highest scoring one.
+type ScoringMatcher struct {
+	// How much the title and artists contribute to the
+	// confidence. They should sum to 1.
+	TitleWeight  float64
+	ArtistWeight float64
+
+	// Variants are penalized by VariantPenalty if they appear
+	// in a candidate's title, but not the song's name.
+	Variants       []string
+	VariantPenalty float64
+
+	// URI schemes (i.e. "local", "spotify") in order of preference.
+	// Preferred backends win between otherwise similar candidates,
+	// but don't affect the confidence.
+	PreferredBackends []string
+
+	// Candidates with a confidence below MinConfidence are rejected.
+	MinConfidence float64
+}
+
+// DefaultMinConfidence is the minimum confidence of the matcher
+// servers use if they aren't given one.
+const DefaultMinConfidence = 0.6
+
+func NewScoringMatcher(minConfidence float64, preferredBackends []string) *ScoringMatcher {
+	return &ScoringMatcher{
+		TitleWeight:       0.7,
+		ArtistWeight:      0.3,
+		Variants:          DefaultVariants,
+		VariantPenalty:    0.25,
+		PreferredBackends: preferredBackends,
+		MinConfidence:     minConfidence,
+	}
+}
+
+func (s *ScoringMatcher) Match(song playsource.Song, candidates []mopidy.Track) (track mopidy.Track, confidence float64, ok bool) {
+	bestRank, closest := -1.0, -1.0
+	for _, c := range candidates {
+		score := s.Score(song, c)
+
+		// Rejected candidates can't win, however preferred their
+		// backend. The closest is only kept to say how close it was.
+		if score < s.MinConfidence {
+			if !ok && score > closest {
+				closest = score
+				track = c
+				confidence = score
+			}
+			continue
+		}
+
+		// The backend bonus is small enough that it only
+		// decides between candidates that are nearly tied.
+		rank := score + 0.05*s.backendPreference(c.URI)
+		if rank > bestRank {
+			bestRank = rank
+			track = c
+			confidence = score
+			ok = true
+		}
+	}
+
+	return track, confidence, ok
+}
+
+// Score returns the confidence, from 0 to 1, that the track is the song.
+func (s *ScoringMatcher) Score(song playsource.Song, track mopidy.Track) float64 {
+	score := s.TitleWeight*titleSimilarity(song.Name, track.Name) +
+		s.ArtistWeight*artistOverlap(song.Artists, track.Artists)
+
+	requested := words(song.Name)
+	title := words(track.Name)
+	for _, v := range s.Variants {
+		if title[v] && !requested[v] {
+			score -= s.VariantPenalty
+		}
+	}
+
+	if score < 0 {
+		return 0
+	}
+
+	return score
+}
+
+// backendPreference returns 1 for the most preferred backend,
+// approaching 0 for the least preferred, and 0 for unlisted ones.
+func (s *ScoringMatcher) backendPreference(uri string) float64 {
+	for i, b := range s.PreferredBackends {
+		if strings.HasPrefix(uri, b+":") {
+			return float64(len(s.PreferredBackends)-i) / float64(len(s.PreferredBackends))
+		}
+	}
+
+	return 0
+}
+
+// normalize lowercases s, and replaces punctuation with spaces.
+func normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func words(s string) map[string]bool {
+	w := make(map[string]bool)
+	for _, f := range strings.Fields(normalize(s)) {
+		w[f] = true
+	}
+	return w
+}
+
+// baseTitle strips decorations like "(Radio Edit)" or " - Remastered"
+// from a title.
+func baseTitle(s string) string {
+	if i := strings.IndexAny(s, "(["); i > 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, " - "); i > 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+func titleSimilarity(requested, title string) float64 {
+	sim := similarity(normalize(requested), normalize(title))
+
+	// Requests often leave off decorations, so compare
+	// the base titles as well.
+	if base := similarity(normalize(baseTitle(requested)), normalize(baseTitle(title))); base > sim {
+		// Slightly prefer an exact match over a base match.
+		sim = 0.95 * base
+	}
+
+	return sim
+}
+
+// artistOverlap returns the fraction of requested artists that
+// appear in the track's artists. If no artists were requested,
+// any track is a full match.
+func artistOverlap(requested []string, artists []mopidy.Artist) float64 {
+	if len(requested) == 0 {
+		return 1
+	}
+
+	var matched int
+	for _, r := range requested {
+		for _, a := range artists {
+			if similarity(normalize(r), normalize(a.Name)) >= 0.8 {
+				matched++
+				break
+			}
+		}
+	}
+
+	return float64(matched) / float64(len(requested))
+}
+
+// similarity returns 1 - the normalized levenshtein distance
+// between a and b.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j] + 1
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+			if prev[j-1]+cost < cur[j] {
+				cur[j] = prev[j-1] + cost
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTrack(uri, name string, artists ...string) mopidy.Track {
+	t := mopidy.Track{URI: uri, Name: name}
+	for _, a := range artists {
+		t.Artists = append(t.Artists, mopidy.Artist{Name: a})
+	}
+	return t
+}
+
+func TestScoringMatcher(t *testing.T) {
+	m := NewScoringMatcher(0.6, []string{"local", "spotify"})
+
+	song := playsource.Song{Name: "Shivers", Artists: []string{"Armin van Buuren"}}
+	candidates := []mopidy.Track{
+		testTrack("spotify:track:karaoke", "Shivers (Karaoke Version)", "Armin van Buuren"),
+		testTrack("spotify:track:live", "Shivers - Live", "Armin van Buuren"),
+		testTrack("spotify:track:other", "Shivers", "Ed Sheeran"),
+		testTrack("spotify:track:original", "Shivers", "Armin van Buuren", "Nadia Ali"),
+	}
+
+	match, confidence, ok := m.Match(song, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "spotify:track:original", match.URI)
+	assert.InDelta(t, 1.0, confidence, 0.001)
+
+	// Asking for a variant shouldn't penalize it.
+	song.Name = "Shivers (Live)"
+	match, _, ok = m.Match(song, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "spotify:track:live", match.URI)
+
+	// Preferred backends win ties.
+	song.Name = "Shivers"
+	candidates = append(candidates, testTrack("local:track:original", "Shivers", "Armin van Buuren"))
+	match, _, ok = m.Match(song, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "local:track:original", match.URI)
+
+	// Nothing similar enough, though we still hear how close it came.
+	song = playsource.Song{Name: "Becoming Harmonious", Artists: []string{"The Glitch Mob"}}
+	candidates = []mopidy.Track{testTrack("local:track:other", "Becoming Insane", "Infected Mushroom")}
+	match, confidence, ok = m.Match(song, candidates)
+	assert.False(t, ok)
+	assert.Equal(t, "local:track:other", match.URI)
+	assert.True(t, confidence < m.MinConfidence)
+
+	_, _, ok = m.Match(song, nil)
+	assert.False(t, ok)
+}
+
+func TestScoringMatcherThreshold(t *testing.T) {
+	m := &ScoringMatcher{
+		TitleWeight:       0.7,
+		ArtistWeight:      0.02,
+		PreferredBackends: []string{"local", "spotify"},
+		MinConfidence:     0.71,
+	}
+
+	// The local track scores 0.70, just under the minimum, but its
+	// backend bonus would rank it above the spotify track's 0.72.
+	song := playsource.Song{Name: "Shivers", Artists: []string{"Armin van Buuren"}}
+	candidates := []mopidy.Track{
+		testTrack("local:track:close", "Shivers", "Someone Else"),
+		testTrack("spotify:track:accepted", "Shivers", "Armin van Buuren"),
+	}
+
+	// Preferred backends can't lift a rejected candidate
+	// above an accepted one.
+	match, confidence, ok := m.Match(song, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "spotify:track:accepted", match.URI)
+	assert.InDelta(t, 0.72, confidence, 0.001)
+}
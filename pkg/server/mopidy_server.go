@@ -1,8 +1,11 @@
 package server
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"io"
 	"log"
+	"net/http"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,47 +14,183 @@ import (
 
 	"golang.org/x/net/context"
 
+	"github.com/crowdsoundsystem/playsource/pkg/history"
 	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
 	"github.com/crowdsoundsystem/playsource/pkg/playsource"
 )
 
+// DefaultPollInterval is how often servers poll mopidy,
+// unless configured otherwise.
+const DefaultPollInterval = 10 * time.Second
+
+// MopidyConfig configures a MopidyServer.
+type MopidyConfig struct {
+	// Mopidy RPC endpoint.
+	URL string
+
+	MaxQueueSize int
+
+	// How often the tracklist is polled when mopidy's events can't
+	// be relied on. Defaults to DefaultPollInterval if not positive.
+	PollInterval time.Duration
+
+	// Defaults to a history.MemoryStore.
+	History history.Store
+
+	// Defaults to a ScoringMatcher with DefaultMinConfidence.
+	Matcher Matcher
+
+	// Search results are cached in SearchCache, and at most
+	// ResolveParallelism songs are searched for at once. The
+	// cache defaults to one in memory, with DefaultSearchCacheTTL.
+	SearchCache        *SearchCache
+	ResolveParallelism int
+
+	// The primary loses its lease if it doesn't send a request for
+	// LeaseTimeout. Defaults to DefaultLeaseTimeout if not positive.
+	LeaseTimeout time.Duration
+
+	// Defaults to DefaultVolumeVoteConfig.
+	VolumeVote VolumeVoteConfig
+
+	// Skips aren't voted on by default. The window
+	// defaults to DefaultSkipVoteConfig's.
+	SkipVote SkipVoteConfig
+
+	Playback PlaybackConfig
+
+	// Requests to mopidy time out after RequestTimeout, or the deadline of
+	// the RPC they're made for, if it's sooner. Defaults to
+	// mopidy.DefaultTimeout.
+	RequestTimeout time.Duration
+
+	// How requests that don't reach mopidy are retried. Defaults to
+	// mopidy.DefaultRetryPolicy if Attempts is 0.
+	Retry mopidy.RetryPolicy
+
+	// Defaults to RealClock.
+	Clock Clock
+}
+
 type MopidyServer struct {
-	client *mopidy.Client
+	client   *mopidy.Client
+	matcher  Matcher
+	resolver *Resolver
 
 	queueSize    int32
 	maxQueueSize int
 	pollInterval time.Duration
 
-	nowPlayingLock sync.Mutex
-	nowPlaying     playsource.Song
+	// The session outlives the primary's stream, so that a primary
+	// can reconnect and resume it. controlLock is held by whichever
+	// stream is driving the session.
+	sessionLock sync.Mutex
+	session     *MopidySession
+	sessionID   string
+	controlLock sync.Mutex
+
+	history history.Store
+
+	// When a client connects, they attempt to obtain the lease. If
+	// they do, they are considered primary, and no other client can
+	// control the server (though they may observe or query it) until
+	// the primary disconnects, stops heartbeating, or is taken over.
+	lease     *LeaseManager
+	observers *broadcaster
+
+	// Playback events for WatchPlayback.
+	events *eventLog
+
+	volumeVoter *volumeVoter
+	skipVoter   *skipVoter
+
+	// The last VOLUME_CHANGED event published, if any.
+	volumeLock sync.Mutex
+	volume     *playsource.PlaybackEvent
+
+	settings *playbackSettings
+	fader    *fader
 
-	historyLock sync.Mutex
-	history     playsource.Song
+	clock Clock
 
-	// Buffered channel of size one. When a client connects, they
-	// attempt to obtain a 'lease' from this channel. If they receive
-	// a value, they are considered master, and no other client can
-	// control the server (though they may query it). When master
-	// disconnects, they return their 'lease' to this channel
-	master   chan struct{}
-	skipSong chan struct{}
+	// Cancelled when the server is closed, to stop its background work.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func NewMopidyServer(url string, maxQueueSize int, pollInterval time.Duration) *MopidyServer {
+func NewMopidyServer(config MopidyConfig) *MopidyServer {
+	if config.RequestTimeout == 0 {
+		config.RequestTimeout = mopidy.DefaultTimeout
+	}
+	if config.Retry.Attempts == 0 {
+		config.Retry = mopidy.DefaultRetryPolicy
+	}
+	client := mopidy.NewClientWithHTTPClient(config.URL, &http.Client{Timeout: config.RequestTimeout})
+	client.SetRetryPolicy(config.Retry)
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.Clock == nil {
+		config.Clock = RealClock
+	}
+	if config.History == nil {
+		config.History = history.NewMemoryStore()
+	}
+	if config.Matcher == nil {
+		config.Matcher = NewScoringMatcher(DefaultMinConfidence, nil)
+	}
+	if config.SearchCache == nil {
+		config.SearchCache = NewSearchCache(DefaultSearchCacheTTL, config.Clock)
+	}
+
 	s := &MopidyServer{
-		client:       mopidy.NewClient(url),
-		maxQueueSize: maxQueueSize,
-		pollInterval: pollInterval,
-		master:       make(chan struct{}, 1),
+		client:       client,
+		matcher:      config.Matcher,
+		resolver:     NewResolver(client, config.SearchCache, config.ResolveParallelism),
+		maxQueueSize: config.MaxQueueSize,
+		pollInterval: config.PollInterval,
+		history:      config.History,
+		lease:        NewLeaseManager(config.LeaseTimeout, config.Clock),
+		observers:    newBroadcaster(),
+		events:       newEventLog(config.Clock),
+		settings:     newPlaybackSettings(config.Playback),
+		fader:        newFader(config.Clock, client.GetVolume, client.SetVolume),
+		clock:        config.Clock,
+	}
+
+	if config.VolumeVote == (VolumeVoteConfig{}) {
+		config.VolumeVote = DefaultVolumeVoteConfig
+	}
+	s.volumeVoter = newVolumeVoter(config.VolumeVote, config.Clock, s.fader.getVolume, s.fader.setVolume)
+
+	if config.SkipVote.Window == 0 {
+		config.SkipVote.Window = DefaultSkipVoteConfig.Window
 	}
+	s.skipVoter = newSkipVoter(config.SkipVote, config.Clock)
 
-	// Initial lease
-	s.master <- struct{}{}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go crossfade(s.ctx, client, s.settings, s.fader, config.Clock)
 
 	log.Println("created")
 	return s
 }
 
+// Close stops the server's background work, and its session.
+func (m *MopidyServer) Close() error {
+	m.cancel()
+
+	m.sessionLock.Lock()
+	defer m.sessionLock.Unlock()
+
+	if m.session != nil {
+		m.session.Close()
+		m.session = nil
+	}
+
+	return nil
+}
+
 func queueStream(stream playsource.Playsource_QueueSongServer) <-chan playsource.QueueSongRequest {
 	inbound := make(chan playsource.QueueSongRequest)
 
@@ -79,24 +218,76 @@ func queueStream(stream playsource.Playsource_QueueSongServer) <-chan playsource
 func (m *MopidyServer) QueueSong(stream playsource.Playsource_QueueSongServer) error {
 	log.Println("Client connected")
 
-	select {
-	case <-m.master:
-	default:
-		return errf(codes.Unavailable, "A master already exists")
+	ctx := stream.Context()
+	inbound := queueStream(stream)
+
+	// The first request identifies the controller.
+	first, ok := <-inbound
+	if !ok {
+		return nil
 	}
 
-	defer func() { m.master <- struct{}{} }()
+	handshake := first.Handshake
+	if handshake == nil {
+		handshake = &playsource.Handshake{}
+	}
 
-	atomic.StoreInt32(&m.queueSize, 0)
-	inbound := queueStream(stream)
-	session, err := NewMopidySession(m.client, 2*m.maxQueueSize, m.pollInterval)
+	if handshake.Observe {
+		log.Printf("%q is observing", handshake.Controller)
+		return observe(stream, inbound, m.lease, m.observers)
+	}
+
+	token, revoked, err := m.lease.Acquire(handshake.Controller, handshake.Takeover)
+	if err != nil {
+		return errf(codes.Unavailable, "A primary already exists: %v", err)
+	}
+	defer m.lease.Release(token)
+	log.Printf("%q is primary (token %v)", handshake.Controller, token)
+
+	m.controlLock.Lock()
+	defer m.controlLock.Unlock()
+
+	session, info, err := m.attach(ctx, handshake.SessionId)
 	if err != nil {
 		return err
 	}
-	defer session.Close()
+
+	if first.Handshake != nil {
+		err := stream.Send(&playsource.QueueSongResponse{
+			Lease: &playsource.Lease{
+				Holder: handshake.Controller,
+				Token:  token,
+			},
+			Session: info,
+		})
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// Songs that finished since the last primary left are replayed.
+	if err := m.deliver(stream, session); err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Songs are resolved concurrently, but queued in the order they
+	// were requested, so we keep a channel per pending resolution.
+	var pending []pendingSong
+	if first.Song != nil {
+		pending = append(pending, pendingSong{first, m.resolver.Resolve(ctx, *first.Song)})
+	}
 
 	log.Println("Starting loop")
 	for {
+		var resolved <-chan Resolution
+		if len(pending) > 0 {
+			resolved = pending[0].resolved
+		}
+
 		select {
 		case req, ok := <-inbound:
 			if !ok {
@@ -105,161 +296,898 @@ func (m *MopidyServer) QueueSong(stream playsource.Playsource_QueueSongServer) e
 				return nil
 			}
 
-			// Search for song
-			args := mopidy.SearchArgs{
-				TrackName: []string{req.Song.Name},
-				Artist:    req.Song.Artists,
-			}
-
-			searchResults, err := m.client.Search(args)
-			if err != nil {
-				log.Println("Search error:", err)
-				return err
-			}
-
-			tracks := make([]mopidy.Track, 0)
-			for _, r := range searchResults {
-				tracks = append(tracks, r.Tracks...)
+			if err := m.lease.Heartbeat(token); err != nil {
+				return errf(codes.Aborted, "Lease was taken over")
 			}
 
-			// Did we finy any results?
-			if len(tracks) == 0 {
-				err := stream.Send(&playsource.QueueSongResponse{
-					SongId: req.Song.SongId,
-					Queued: false,
-					Found:  false,
-				})
-				if err == io.EOF {
-					return nil
-				} else if err != nil {
-					return err
-				}
-
+			// Requests without a song are just heartbeats.
+			if req.Song == nil {
 				continue
 			}
 
-			// Check server queue size.
-			if int(atomic.LoadInt32(&m.queueSize)) >= m.maxQueueSize {
-				log.Println("Internal queue size reached: ", atomic.LoadInt32(&m.queueSize))
-				atomic.AddInt32(&m.queueSize, -1)
-				err := stream.Send(&playsource.QueueSongResponse{
-					SongId: req.Song.SongId,
-					Queued: false,
-					Found:  true,
-				})
-
-				if err == io.EOF {
-					return nil
-				} else if err != nil {
-					return err
-				}
-			}
+			pending = append(pending, pendingSong{req, m.resolver.Resolve(ctx, *req.Song)})
+		case <-revoked:
+			holder, _ := m.lease.Holder()
+			log.Printf("%q was taken over by %q", handshake.Controller, holder)
+			return errf(codes.Aborted, "Lease was taken over by %q", holder)
+		case res := <-resolved:
+			req := pending[0].req
+			pending = pending[1:]
 
-			// Just take the first result?
-			tracksAdded, err := m.client.AddTracks(tracks[0:1])
+			resp, err := m.queueSong(ctx, session, token, req, res)
 			if err != nil {
 				return err
 			}
 
-			if len(tracksAdded) == 0 {
-				err := stream.Send(&playsource.QueueSongResponse{
-					SongId: req.Song.SongId,
-					Queued: false,
-					Found:  false,
-				})
-				if err == io.EOF {
-					return nil
-				} else if err != nil {
-					return err
-				}
-
+			// If the song was queued, we'll respond once it's finished.
+			if resp == nil {
 				continue
 			}
 
-			log.Println("Queuing:", req.Song)
-			err = session.QueueSong(SongTrackPair{
-				Song:  *req.Song,
-				Track: tracks[0],
-			})
-			if err != nil {
-				log.Println("Error queueing song:", err)
-				return err
-			}
-
-			// If we aren't playing (for whatever reason), make sure we play.
-			state, err := m.client.CurrentState()
-			if err != nil {
+			err = m.send(stream, resp)
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
 				return err
 			}
-
-			switch state {
-			case mopidy.Stopped:
-				err = m.client.Play()
-				if err != nil {
-					return err
-				}
-				break
-			case mopidy.Paused:
-				err = m.client.Resume()
-				if err != nil {
-					return err
-				}
-				break
-			}
-			atomic.AddInt32(&m.queueSize, 1)
-		case song := <-session.FinishedChan():
-			log.Println("finished:", song)
-			err := stream.Send(&playsource.QueueSongResponse{
-				SongId:   song.Song.SongId,
-				Finished: true,
-				Found:    true,
-			})
+		case <-session.Finished():
+			err := m.deliver(stream, session)
 			if err == io.EOF {
 				return nil
 			} else if err != nil {
 				return err
 			}
+		}
+	}
+}
 
-			atomic.AddInt32(&m.queueSize, -1)
+// attach resumes the session identified by id, if it's the current
+// session. Otherwise, the current session is retired, and a new one
+// is started, resetting mopidy.
+func (m *MopidyServer) attach(ctx context.Context, id string) (*MopidySession, *playsource.Session, error) {
+	m.sessionLock.Lock()
+	defer m.sessionLock.Unlock()
+
+	if id != "" && m.session != nil && id == m.sessionID {
+		log.Println("Resuming session:", id)
+		info := &playsource.Session{
+			Id:      id,
+			Resumed: true,
+		}
+
+		for _, s := range m.session.InFlight() {
+			song := s.Song
+			info.InFlight = append(info.InFlight, &song)
 		}
+
+		return m.session, info, nil
+	}
+
+	if m.session != nil {
+		m.session.Close()
+		m.session = nil
 	}
+
+	atomic.StoreInt32(&m.queueSize, 0)
+	session, err := NewMopidySession(ctx, m.client, m.events, m.pollInterval, m.clock, m.recordFinished, m.volumeChanged)
+	if err != nil {
+		return nil, nil, backendError(err)
+	}
+
+	id, err = newSessionID()
+	if err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+
+	log.Println("Started session:", id)
+	m.session = session
+	m.sessionID = id
+
+	return session, &playsource.Session{Id: id}, nil
+}
+
+// deliver sends the songs that have finished in session to the primary.
+// Songs are only forgotten once they've been sent, so that if the stream
+// breaks, they're sent again if the session is resumed.
+func (m *MopidyServer) deliver(stream playsource.Playsource_QueueSongServer, session *MopidySession) error {
+	for _, song := range session.Undelivered() {
+		log.Println("finished:", song)
+		if err := m.send(stream, song.response()); err != nil {
+			return err
+		}
+
+		session.Delivered(song)
+	}
+
+	return nil
+}
+
+// recordFinished accounts for a song as it finishes, whether or not
+// the primary is there to be told.
+func (m *MopidyServer) recordFinished(song FinishedSong) {
+	atomic.AddInt32(&m.queueSize, -1)
+
+	// Songs that never played aren't part of the history.
+	if song.Failed {
+		return
+	}
+
+	err := m.history.Record(history.Entry{
+		Song:     song.Song,
+		URI:      song.Track.URI,
+		Started:  song.Started,
+		Finished: song.Finished,
+		Skipped:  song.Skipped,
+	})
+	if err != nil {
+		log.Println("Error recording history:", err)
+	}
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// send sends resp to the primary, and all observers.
+func (m *MopidyServer) send(stream playsource.Playsource_QueueSongServer, resp *playsource.QueueSongResponse) error {
+	m.observers.broadcast(resp)
+	return stream.Send(resp)
+}
+
+// pendingSong is a song that's waiting to be resolved.
+type pendingSong struct {
+	req      playsource.QueueSongRequest
+	resolved <-chan Resolution
+}
+
+// queueSong picks the best track for a resolved song and queues it where
+// req asks. If the song couldn't be queued, the returned response describes
+// why. Otherwise, the response is nil.
+func (m *MopidyServer) queueSong(ctx context.Context, session *MopidySession, token uint64, req playsource.QueueSongRequest, res Resolution) (*playsource.QueueSongResponse, error) {
+	song, tracks := res.Song, res.Tracks
+	resp := &playsource.QueueSongResponse{
+		SongId: song.SongId,
+	}
+
+	if res.Err != nil {
+		log.Println("Search error:", res.Err)
+		resp.Reason = playsource.QueueSongResponse_BACKEND_ERROR
+		return resp, nil
+	}
+
+	// Did we find any results?
+	if len(tracks) == 0 {
+		resp.Reason = playsource.QueueSongResponse_NOT_FOUND
+		return resp, nil
+	}
+
+	// Did we find a good enough match?
+	track, confidence, ok := m.matcher.Match(song, tracks)
+	resp.Track = trackInfo(track)
+	resp.Confidence = confidence
+	if !ok {
+		log.Printf("No match for %v (%v candidates, best confidence %.2f)", song, len(tracks), confidence)
+		resp.Reason = playsource.QueueSongResponse_LOW_CONFIDENCE
+		return resp, nil
+	}
+
+	// Check server queue size.
+	if int(atomic.LoadInt32(&m.queueSize)) >= m.maxQueueSize {
+		log.Println("Internal queue size reached: ", atomic.LoadInt32(&m.queueSize))
+		resp.Found = true
+		resp.Reason = playsource.QueueSongResponse_QUEUE_FULL
+		return resp, nil
+	}
+
+	// We may have been taken over while resolving.
+	if err := m.lease.Check(token); err != nil {
+		return nil, errf(codes.Aborted, "Lease was taken over")
+	}
+
+	log.Printf("Matched %v to %v (confidence %.2f)", song, track.URI, confidence)
+	position, err := m.insertionPosition(ctx, req)
+	if err != nil {
+		log.Println("Error finding insertion position:", err)
+		resp.Reason = playsource.QueueSongResponse_BACKEND_ERROR
+		return resp, nil
+	}
+
+	tracksAdded, err := m.client.InsertTracks(ctx, []mopidy.Track{track}, position)
+	if err != nil {
+		log.Println("Error adding track:", err)
+		resp.Reason = playsource.QueueSongResponse_BACKEND_ERROR
+		return resp, nil
+	}
+
+	if len(tracksAdded) == 0 {
+		resp.Reason = playsource.QueueSongResponse_ADD_REJECTED
+		return resp, nil
+	}
+
+	log.Println("Queuing:", song)
+	pair := SongTrackPair{
+		Song:  song,
+		Track: track,
+		TLID:  tracksAdded[0].TLID,
+	}
+
+	// The song is counted before the session can finish it.
+	atomic.AddInt32(&m.queueSize, 1)
+	if err := session.QueueSong(pair); err != nil {
+		log.Println("Error queueing song:", err)
+		return nil, err
+	}
+
+	// If we can't get it playing, we take the song back, so the client can
+	// try again. If that fails too, it stays queued, and is reported once
+	// it finishes, like any other song.
+	if err := m.startPlaying(ctx, session, position); err != nil {
+		log.Println("Error starting playback:", err)
+
+		if removed, err := m.unqueue(ctx, session, pair); err != nil {
+			log.Println("Error removing track:", err)
+		} else if removed {
+			resp.Reason = playsource.QueueSongResponse_BACKEND_ERROR
+			return resp, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// unqueue removes a queued song from the tracklist and the session,
+// returning whether it was still queued. Songs that finished first
+// are reported as usual.
+func (m *MopidyServer) unqueue(ctx context.Context, session *MopidySession, pair SongTrackPair) (bool, error) {
+	tlids := []int{pair.TLID}
+	session.SetRemoving(tlids, true)
+	if _, err := m.client.RemoveTracks(ctx, tlids); err != nil {
+		session.SetRemoving(tlids, false)
+		return false, err
+	}
+
+	if !session.Remove(pair.Song.SongId) {
+		return false, nil
+	}
+
+	atomic.AddInt32(&m.queueSize, -1)
+	return true, nil
+}
+
+// startPlaying makes sure mopidy is playing, once a song has been queued
+// at position.
+func (m *MopidyServer) startPlaying(ctx context.Context, session *MopidySession, position int) error {
+	// If it didn't go at the end, the session needs to know where it went.
+	if position >= 0 {
+		tlTracks, err := m.client.TlTracks(ctx)
+		if err != nil {
+			return err
+		}
+
+		session.Sync(tlTracks)
+	}
+
+	// If we aren't playing (for whatever reason), make sure we play.
+	state, err := m.client.CurrentState(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch state {
+	case mopidy.Stopped:
+		return m.client.Play(ctx)
+	case mopidy.Paused:
+		return m.client.Resume(ctx)
+	}
+
 	return nil
 }
 
+// insertionPosition returns the tracklist position to queue req's song
+// at, or -1 to append it.
+func (m *MopidyServer) insertionPosition(ctx context.Context, req playsource.QueueSongRequest) (int, error) {
+	if req.Insertion == playsource.QueueSongRequest_APPEND {
+		return -1, nil
+	}
+
+	tlTracks, current, err := m.tracklist(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Songs can't go before the playing song.
+	earliest := 0
+	if current != nil {
+		earliest = tracklistPosition(tlTracks, current.TLID) + 1
+	}
+
+	switch req.Insertion {
+	case playsource.QueueSongRequest_PLAY_NEXT:
+		return earliest, nil
+	case playsource.QueueSongRequest_AT_POSITION:
+		position := int(req.Position)
+		if position < earliest {
+			return earliest, nil
+		} else if position >= len(tlTracks) {
+			return -1, nil
+		}
+
+		return position, nil
+	default:
+		return -1, nil
+	}
+}
+
+func trackInfo(track mopidy.Track) *playsource.Track {
+	info := &playsource.Track{
+		Uri:      track.URI,
+		Name:     track.Name,
+		LengthMs: int32(track.Length),
+	}
+
+	for _, a := range track.Artists {
+		info.Artists = append(info.Artists, a.Name)
+	}
+
+	return info
+}
+
 func (m *MopidyServer) SkipSong(ctx context.Context, req *playsource.SkipSongRequest) (*playsource.SkipSongResponse, error) {
-	err := m.client.Next()
+	var current *mopidy.TlTrack
+	var position int
+
+	var batch mopidy.Batch
+	batch.CurrentTlTrack(&current)
+	batch.TimePosition(&position)
+	if err := m.client.Batch(ctx, &batch); err != nil {
+		return nil, backendError(err)
+	}
+
+	var song *playsource.Song
+	session := m.currentSession()
+	if current != nil && session != nil {
+		if pair, ok := session.Lookup(current.TLID); ok {
+			song = &pair.Song
+		}
+	}
+
+	if err := checkExpected(req, song); err != nil {
+		return nil, err
+	}
+
+	// There's nothing to skip.
+	if current == nil {
+		return &playsource.SkipSongResponse{}, nil
+	}
+
+	resp, err := m.skipVoter.vote(req, current.TLID)
+	if err != nil || !resp.Skipped {
+		return resp, err
+	}
+
+	// The fade can't outlast the song, and stops if the song ends anyway,
+	// since skipping then would skip the song after it.
+	fade := m.settings.skipFade()
+	if remaining := time.Duration(current.Track.Length-position) * time.Millisecond; current.Track.Length > 0 && remaining < fade {
+		fade = remaining
+	}
+
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	defer stopWatching()
+	ended := trackEnded(watchCtx, m.client, m.clock, current.TLID)
+
+	var alreadyEnded, marked bool
+	err = m.fader.around(ctx, fade, ended, func() error {
+		playing, err := m.client.CurrentTlTrack(ctx)
+		if err != nil {
+			return err
+		}
+
+		if playing == nil || playing.TLID != current.TLID {
+			alreadyEnded = true
+			return nil
+		}
+
+		// Mark it before skipping, since it may finish before Next()
+		// returns. Songs we queued are reported as skipped when they
+		// finish, so SKIPPED comes before TRACK_FINISHED.
+		if session != nil {
+			_, marked = session.SetSkipped(current.TLID, req)
+		}
+
+		return m.client.Next(ctx)
+	})
 	if err != nil {
-		err = errf(codes.Internal, err.Error())
+		if marked {
+			session.SetSkipped(current.TLID, nil)
+		}
+
+		return nil, backendError(err)
+	}
+
+	// It finished, rather than being skipped.
+	if alreadyEnded {
+		log.Printf("%v ended before it could be skipped", current.Track.Name)
+		return resp, nil
+	}
+
+	log.Printf("Skipped %v (requester: %q, reason: %q)", current.Track.Name, req.Requester, req.Reason)
+
+	// Nothing else reports songs we didn't queue.
+	if !marked {
+		e := trackEvent(playsource.PlaybackEvent_SKIPPED, song, current.Track)
+		e.Reason = req.Reason
+		e.Requester = req.Requester
+		m.events.publish(e)
 	}
 
-	return &playsource.SkipSongResponse{}, err
+	return resp, nil
 }
 
-func (m *MopidyServer) GetPlaying(ctx context.Context, req *playsource.GetPlayingRequest) (*playsource.GetPlayingResponse, error) {
-	m.nowPlayingLock.Lock()
-	song := m.nowPlaying
-	m.nowPlayingLock.Unlock()
+func (m *MopidyServer) Pause(ctx context.Context, req *playsource.PauseRequest) (*playsource.PauseResponse, error) {
+	if err := m.client.Pause(ctx); err != nil {
+		return nil, backendError(err)
+	}
 
-	return &playsource.GetPlayingResponse{Song: &song}, nil
+	return &playsource.PauseResponse{}, nil
 }
 
-func (m *MopidyServer) GetPlayHistory(req *playsource.GetPlayHistoryRequest, stream playsource.Playsource_GetPlayHistoryServer) error {
-	/*
-		m.historyLock.Lock()
-		history := make([]playsource.Song, len(m.history))
-		copy(history, m.history)
-		m.historyLock.Unlock()
-
-		for i := range history {
-			resp := &playsource.GetPlayHistoryResponse{
-				Song: &history[i],
+func (m *MopidyServer) Resume(ctx context.Context, req *playsource.ResumeRequest) (*playsource.ResumeResponse, error) {
+	state, err := m.client.CurrentState(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	// Mopidy only resumes paused playback, so stopped playback is
+	// started over instead.
+	if state == mopidy.Stopped {
+		err = m.client.Play(ctx)
+	} else {
+		err = m.client.Resume(ctx)
+	}
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	return &playsource.ResumeResponse{}, nil
+}
+
+func (m *MopidyServer) Stop(ctx context.Context, req *playsource.StopRequest) (*playsource.StopResponse, error) {
+	if err := m.client.Stop(ctx); err != nil {
+		return nil, backendError(err)
+	}
+
+	return &playsource.StopResponse{}, nil
+}
+
+func (m *MopidyServer) Seek(ctx context.Context, req *playsource.SeekRequest) (*playsource.SeekResponse, error) {
+	if req.PositionMs < 0 {
+		return nil, errf(codes.InvalidArgument, "Position must not be negative")
+	}
+
+	if err := m.client.Seek(ctx, int(req.PositionMs)); err != nil {
+		return nil, backendError(err)
+	}
+
+	return &playsource.SeekResponse{}, nil
+}
+
+func (m *MopidyServer) Previous(ctx context.Context, req *playsource.PreviousRequest) (*playsource.PreviousResponse, error) {
+	if err := m.client.Previous(ctx); err != nil {
+		return nil, backendError(err)
+	}
+
+	return &playsource.PreviousResponse{}, nil
+}
+
+func (m *MopidyServer) currentSession() *MopidySession {
+	m.sessionLock.Lock()
+	defer m.sessionLock.Unlock()
+
+	return m.session
+}
+
+// tracklist returns mopidy's tracklist, and the entry that's playing, if any.
+func (m *MopidyServer) tracklist(ctx context.Context) ([]mopidy.TlTrack, *mopidy.TlTrack, error) {
+	tlTracks, err := m.client.TlTracks(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := m.client.CurrentTlTrack(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tlTracks, current, nil
+}
+
+// tracklistPosition returns the position of the entry
+// tlid in tlTracks, or -1 if there isn't one.
+func tracklistPosition(tlTracks []mopidy.TlTrack, tlid int) int {
+	for i, t := range tlTracks {
+		if t.TLID == tlid {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (m *MopidyServer) ListQueue(ctx context.Context, req *playsource.ListQueueRequest) (*playsource.ListQueueResponse, error) {
+	tlTracks, current, err := m.tracklist(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	session := m.currentSession()
+	resp := &playsource.ListQueueResponse{}
+	for _, t := range tlTracks {
+		entry := &playsource.QueueEntry{
+			Track:   trackInfo(t.Track),
+			Playing: current != nil && current.TLID == t.TLID,
+		}
+
+		if session != nil {
+			if pair, ok := session.Lookup(t.TLID); ok {
+				song := pair.Song
+				entry.Song = &song
 			}
+		}
 
-			if err := stream.Send(resp); err != nil {
-				return err
+		resp.Entries = append(resp.Entries, entry)
+	}
+
+	return resp, nil
+}
+
+func (m *MopidyServer) RemoveFromQueue(ctx context.Context, req *playsource.RemoveFromQueueRequest) (*playsource.RemoveFromQueueResponse, error) {
+	if err := checkPrimary(m.lease, req.LeaseToken); err != nil {
+		return nil, err
+	}
+
+	session := m.currentSession()
+	if session == nil {
+		return nil, errf(codes.NotFound, "Song %v is not queued", req.SongId)
+	}
+
+	pair, ok := session.Find(req.SongId)
+	if !ok {
+		return nil, errf(codes.NotFound, "Song %v is not queued", req.SongId)
+	}
+
+	tlTracks, current, err := m.tracklist(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	// If it's left the tracklist, it's finished, we just haven't heard yet.
+	position := tracklistPosition(tlTracks, pair.TLID)
+	if position < 0 {
+		return nil, errf(codes.FailedPrecondition, "Song %v has already played", req.SongId)
+	}
+
+	if current != nil && current.TLID == tlTracks[position].TLID {
+		return nil, errf(codes.FailedPrecondition, "Song %v is playing, skip it instead", req.SongId)
+	}
+
+	if _, err := m.unqueue(ctx, session, pair); err != nil {
+		return nil, backendError(err)
+	}
+
+	return &playsource.RemoveFromQueueResponse{}, nil
+}
+
+func (m *MopidyServer) MoveInQueue(ctx context.Context, req *playsource.MoveInQueueRequest) (*playsource.MoveInQueueResponse, error) {
+	if err := checkPrimary(m.lease, req.LeaseToken); err != nil {
+		return nil, err
+	}
+
+	session := m.currentSession()
+	if session == nil {
+		return nil, errf(codes.NotFound, "Song %v is not queued", req.SongId)
+	}
+
+	pair, ok := session.Find(req.SongId)
+	if !ok {
+		return nil, errf(codes.NotFound, "Song %v is not queued", req.SongId)
+	}
+
+	tlTracks, current, err := m.tracklist(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	position := tracklistPosition(tlTracks, pair.TLID)
+	if position < 0 {
+		return nil, errf(codes.FailedPrecondition, "Song %v has already played", req.SongId)
+	}
+
+	if req.Position < 0 || int(req.Position) >= len(tlTracks) {
+		return nil, errf(codes.InvalidArgument, "Position must be between 0 and %v", len(tlTracks)-1)
+	}
+
+	playing := -1
+	if current != nil {
+		playing = tracklistPosition(tlTracks, current.TLID)
+	}
+
+	if position == playing {
+		return nil, errf(codes.FailedPrecondition, "Song %v is playing", req.SongId)
+	} else if int(req.Position) <= playing {
+		return nil, errf(codes.InvalidArgument, "Songs can't be moved before the playing song")
+	}
+
+	if err := m.client.MoveTracks(ctx, position, position+1, int(req.Position)); err != nil {
+		return nil, backendError(err)
+	}
+
+	// Keep the session in the same order as the tracklist,
+	// so that finished songs are still attributed correctly.
+	tlTracks, err = m.client.TlTracks(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	session.Sync(tlTracks)
+
+	return &playsource.MoveInQueueResponse{}, nil
+}
+
+func (m *MopidyServer) ClearQueue(ctx context.Context, req *playsource.ClearQueueRequest) (*playsource.ClearQueueResponse, error) {
+	if err := checkPrimary(m.lease, req.LeaseToken); err != nil {
+		return nil, err
+	}
+
+	tlTracks, current, err := m.tracklist(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	var tlids []int
+	for _, t := range tlTracks {
+		if current == nil || t.TLID != current.TLID {
+			tlids = append(tlids, t.TLID)
+		}
+	}
+
+	if len(tlids) == 0 {
+		return &playsource.ClearQueueResponse{}, nil
+	}
+
+	session := m.currentSession()
+	if session != nil {
+		session.SetRemoving(tlids, true)
+	}
+
+	removed, err := m.client.RemoveTracks(ctx, tlids)
+	if err != nil {
+		if session != nil {
+			session.SetRemoving(tlids, false)
+		}
+		return nil, backendError(err)
+	}
+
+	if session != nil {
+		for _, t := range removed {
+			if pair, ok := session.Lookup(t.TLID); ok && session.Remove(pair.Song.SongId) {
+				atomic.AddInt32(&m.queueSize, -1)
 			}
 		}
-	*/
 
-	return nil
+		// Anything that started playing in the meantime wasn't removed.
+		session.SetRemoving(tlids, false)
+	}
+
+	return &playsource.ClearQueueResponse{Removed: int32(len(removed))}, nil
+}
+
+func (m *MopidyServer) GetVolume(ctx context.Context, req *playsource.GetVolumeRequest) (*playsource.GetVolumeResponse, error) {
+	volume, err := m.fader.getVolume(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	muted, err := m.client.GetMute(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	return &playsource.GetVolumeResponse{
+		Volume: int32(volume),
+		Muted:  muted,
+	}, nil
+}
+
+func (m *MopidyServer) SetVolume(ctx context.Context, req *playsource.SetVolumeRequest) (*playsource.SetVolumeResponse, error) {
+	if err := validateVolume(req.Volume); err != nil {
+		return nil, err
+	}
+
+	if err := m.fader.setVolume(ctx, int(req.Volume)); err != nil {
+		return nil, backendError(err)
+	}
+
+	m.volumeChanged(ctx)
+
+	return &playsource.SetVolumeResponse{}, nil
+}
+
+func (m *MopidyServer) SetMute(ctx context.Context, req *playsource.SetMuteRequest) (*playsource.SetMuteResponse, error) {
+	if err := m.client.SetMute(ctx, req.Muted); err != nil {
+		return nil, backendError(err)
+	}
+
+	m.volumeChanged(ctx)
+
+	return &playsource.SetMuteResponse{}, nil
+}
+
+func (m *MopidyServer) VoteVolume(ctx context.Context, req *playsource.VoteVolumeRequest) (*playsource.VoteVolumeResponse, error) {
+	resp, err := m.volumeVoter.vote(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Changed {
+		m.volumeChanged(ctx)
+	}
+
+	return resp, nil
+}
+
+// volumeChanged tells watchers about the current volume, if it's changed
+// since they were last told. The mixer changes as we fade, but the volume
+// we report doesn't.
+func (m *MopidyServer) volumeChanged(ctx context.Context) {
+	m.volumeLock.Lock()
+	defer m.volumeLock.Unlock()
+
+	volume, err := m.fader.getVolume(ctx)
+	if err != nil {
+		log.Println("Error getting volume:", err)
+		return
+	}
+
+	muted, err := m.client.GetMute(ctx)
+	if err != nil {
+		log.Println("Error getting mute:", err)
+		return
+	}
+
+	if m.volume != nil && m.volume.Volume == int32(volume) && m.volume.Muted == muted {
+		return
+	}
+
+	m.volume = &playsource.PlaybackEvent{
+		Type:   playsource.PlaybackEvent_VOLUME_CHANGED,
+		Volume: int32(volume),
+		Muted:  muted,
+	}
+	m.events.publish(m.volume)
+}
+
+func (m *MopidyServer) GetPlaybackSettings(ctx context.Context, req *playsource.GetPlaybackSettingsRequest) (*playsource.GetPlaybackSettingsResponse, error) {
+	settings := m.settings.get()
+	return &playsource.GetPlaybackSettingsResponse{Settings: &settings}, nil
+}
+
+func (m *MopidyServer) SetPlaybackSettings(ctx context.Context, req *playsource.SetPlaybackSettingsRequest) (*playsource.SetPlaybackSettingsResponse, error) {
+	if err := validatePlaybackSettings(req.Settings); err != nil {
+		return nil, err
+	}
+
+	if !req.Settings.Gapless {
+		return nil, errf(codes.Unimplemented, "Mopidy always plays gaplessly")
+	}
+
+	m.settings.set(*req.Settings)
+	return &playsource.SetPlaybackSettingsResponse{}, nil
+}
+
+// GetPlaying asks mopidy what's playing, rather than relying on what the
+// session last saw, so the position is current.
+func (m *MopidyServer) GetPlaying(ctx context.Context, req *playsource.GetPlayingRequest) (*playsource.GetPlayingResponse, error) {
+	var state mopidy.PlayState
+	var current *mopidy.TlTrack
+	var position int
+
+	// Made together, so they describe the same moment.
+	var batch mopidy.Batch
+	batch.CurrentState(&state)
+	batch.CurrentTlTrack(&current)
+	batch.TimePosition(&position)
+	if err := m.client.Batch(ctx, &batch); err != nil {
+		return nil, backendError(err)
+	}
+
+	resp := &playsource.GetPlayingResponse{
+		Song:       &playsource.Song{},
+		State:      playState(state),
+		PositionMs: int32(position),
+	}
+
+	if current != nil {
+		resp.LengthMs = int32(current.Track.Length)
+
+		if session := m.currentSession(); session != nil {
+			if pair, ok := session.Lookup(current.TLID); ok {
+				resp.Song = &pair.Song
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+func playState(state mopidy.PlayState) playsource.PlayState {
+	switch state {
+	case mopidy.Playing:
+		return playsource.PlayState_PLAYING
+	case mopidy.Paused:
+		return playsource.PlayState_PAUSED
+	case mopidy.Stopped:
+		return playsource.PlayState_STOPPED
+	default:
+		return playsource.PlayState_UNKNOWN
+	}
+}
+
+// backendError converts an error from mopidy into an RPC error. Without
+// a mixer, volume RPCs can't succeed until mopidy is configured with one.
+// Requests are made with the RPC's context, so they may have run out of time.
+// Anything else that isn't mopidy's fault is a bug on our part.
+func backendError(err error) error {
+	switch err := err.(type) {
+	case *mopidy.TransportError:
+		return errf(codes.Unavailable, err.Error())
+	case *mopidy.MethodNotFoundError:
+		return errf(codes.Unimplemented, err.Error())
+	case *mopidy.BackendError:
+		return errf(codes.Unknown, err.Error())
+	case *mopidy.NoMixerError:
+		return errf(codes.FailedPrecondition, err.Error())
+	}
+
+	switch err {
+	case context.DeadlineExceeded:
+		return errf(codes.DeadlineExceeded, err.Error())
+	case context.Canceled:
+		return errf(codes.Canceled, err.Error())
+	default:
+		return errf(codes.Internal, err.Error())
+	}
+}
+
+func (m *MopidyServer) Search(ctx context.Context, req *playsource.SearchRequest) (*playsource.SearchResponse, error) {
+	args, err := searchArgs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	searchResults, err := m.client.Search(ctx, args)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	tracks := make([]mopidy.Track, 0)
+	for _, r := range searchResults {
+		tracks = append(tracks, r.Tracks...)
+	}
+
+	return searchResponse(req, tracks), nil
+}
+
+func (m *MopidyServer) GetPlayHistory(req *playsource.GetPlayHistoryRequest, stream playsource.Playsource_GetPlayHistoryServer) error {
+	return sendHistory(m.history, req, stream)
+}
+
+func (m *MopidyServer) WatchPlayback(req *playsource.WatchPlaybackRequest, stream playsource.Playsource_WatchPlaybackServer) error {
+	return streamEvents(m.events, req, stream)
 }
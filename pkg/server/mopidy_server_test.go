@@ -0,0 +1,280 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+func TestBackendError(t *testing.T) {
+	for err, code := range map[error]codes.Code{
+		&mopidy.TransportError{Err: errors.New("connection refused")}: codes.Unavailable,
+		&mopidy.MethodNotFoundError{Method: "core.nonexistent"}:       codes.Unimplemented,
+		&mopidy.BackendError{Message: "Application error"}:            codes.Unknown,
+		&mopidy.ProtocolError{Message: "bad response"}:                codes.Internal,
+		&mopidy.NoMixerError{Method: "core.mixer.get_volume"}:         codes.FailedPrecondition,
+		context.DeadlineExceeded:                                      codes.DeadlineExceeded,
+		context.Canceled:                                              codes.Canceled,
+	} {
+		assert.Equal(t, code, grpc.Code(backendError(err)), err.Error())
+	}
+}
+
+func TestStartPlaying(t *testing.T) {
+	var state string
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fakeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		calls = append(calls, req.Method)
+
+		var result interface{}
+		if req.Method == "core.playback.get_state" {
+			result = state
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	m := &MopidyServer{client: mopidy.NewClient(server.URL)}
+
+	// Queueing while paused resumes the paused song, rather than
+	// starting it over.
+	for s, call := range map[string]string{
+		"paused":  "core.playback.resume",
+		"stopped": "core.playback.play",
+	} {
+		state, calls = s, nil
+		assert.NoError(t, m.startPlaying(context.Background(), nil, -1))
+		assert.Equal(t, []string{"core.playback.get_state", call}, calls)
+	}
+
+	state, calls = "playing", nil
+	assert.NoError(t, m.startPlaying(context.Background(), nil, -1))
+	assert.Equal(t, []string{"core.playback.get_state"}, calls)
+}
+
+func TestResume(t *testing.T) {
+	var state string
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fakeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		calls = append(calls, req.Method)
+
+		var result interface{}
+		if req.Method == "core.playback.get_state" {
+			result = state
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	m := &MopidyServer{client: mopidy.NewClient(server.URL)}
+
+	// Stopped playback can't be resumed, so it's played instead.
+	for s, call := range map[string]string{
+		"paused":  "core.playback.resume",
+		"stopped": "core.playback.play",
+	} {
+		state, calls = s, nil
+		_, err := m.Resume(context.Background(), &playsource.ResumeRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"core.playback.get_state", call}, calls)
+	}
+}
+
+func TestQueueSongUnmatched(t *testing.T) {
+	m := &MopidyServer{matcher: NewScoringMatcher(0.6, nil)}
+	song := playsource.Song{SongId: 1, Name: "Shivers", Artists: []string{"Armin van Buuren"}}
+
+	// None of these need mopidy, so it isn't asked.
+	for reason, res := range map[playsource.QueueSongResponse_Reason]Resolution{
+		playsource.QueueSongResponse_BACKEND_ERROR: {Song: song, Err: errors.New("search failed")},
+		playsource.QueueSongResponse_NOT_FOUND:     {Song: song},
+		playsource.QueueSongResponse_LOW_CONFIDENCE: {Song: song, Tracks: []mopidy.Track{
+			testTrack("spotify:track:other", "Thinking Out Loud", "Ed Sheeran"),
+		}},
+	} {
+		resp, err := m.queueSong(context.Background(), nil, 0, playsource.QueueSongRequest{Song: &song}, res)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, reason, resp.Reason)
+		assert.False(t, resp.Found)
+		assert.Equal(t, int32(1), resp.SongId)
+	}
+}
+
+func TestMopidyServerDefaults(t *testing.T) {
+	s := NewMopidyServer(MopidyConfig{URL: "http://localhost:0/mopidy/rpc"})
+	defer s.Close()
+
+	assert.NotNil(t, s.history)
+	assert.NotNil(t, s.matcher)
+	assert.NotNil(t, s.resolver.cache)
+	assert.Equal(t, DefaultPollInterval, s.pollInterval)
+
+	// Polling without an interval would spin.
+	s = NewMopidyServer(MopidyConfig{URL: "http://localhost:0/mopidy/rpc", PollInterval: -time.Second})
+	defer s.Close()
+
+	assert.Equal(t, DefaultPollInterval, s.pollInterval)
+}
+
+func TestQueueSongStartFailure(t *testing.T) {
+	track := mopidy.Track{Name: "Song", URI: "local:track:song", Artists: []mopidy.Artist{{Name: "Artist"}}}
+
+	var removed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fakeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "core.tracklist.add":
+			resp["result"] = []mopidy.TlTrack{{TLID: 1, Track: track}}
+		case "core.tracklist.remove":
+			removed = true
+			resp["result"] = []mopidy.TlTrack{{TLID: 1, Track: track}}
+		default:
+			resp["error"] = map[string]interface{}{"code": 0, "message": "Application error"}
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := mopidy.NewClient(server.URL)
+	m := &MopidyServer{
+		client:       client,
+		matcher:      NewScoringMatcher(0.5, nil),
+		lease:        NewLeaseManager(time.Minute, RealClock),
+		maxQueueSize: 10,
+	}
+	token, _, err := m.lease.Acquire("a", false)
+	require.NoError(t, err)
+
+	// Mopidy won't play, so the song is taken back, and counted once.
+	session := testSession(client)
+	song := playsource.Song{SongId: 1, Name: "Song", Artists: []string{"Artist"}}
+	resp, err := m.queueSong(context.Background(), session, token, playsource.QueueSongRequest{Song: &song}, Resolution{
+		Song:   song,
+		Tracks: []mopidy.Track{track},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, playsource.QueueSongResponse_BACKEND_ERROR, resp.Reason)
+	assert.True(t, removed)
+	assert.Empty(t, queued(session))
+	assert.Equal(t, int32(0), m.queueSize)
+}
+
+func TestVolumeChanged(t *testing.T) {
+	var muted bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fakeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": muted})
+	}))
+	defer server.Close()
+
+	mixer := 50
+	m := &MopidyServer{
+		client: mopidy.NewClient(server.URL),
+		events: newEventLog(RealClock),
+		fader: newFader(RealClock,
+			func(context.Context) (int, error) { return mixer, nil },
+			func(ctx context.Context, v int) error { mixer = v; return nil },
+		),
+	}
+	_, c := m.events.watch("", 0)
+
+	volumeChanged := func() []*playsource.PlaybackEvent {
+		m.volumeChanged(context.Background())
+		return nextEvents(c)
+	}
+
+	expected := &playsource.PlaybackEvent{Type: playsource.PlaybackEvent_VOLUME_CHANGED, Volume: 50}
+	assert.Equal(t, []*playsource.PlaybackEvent{expected}, volumeChanged())
+
+	// Watchers are only told about changes.
+	assert.Empty(t, volumeChanged())
+
+	muted = true
+	expected = &playsource.PlaybackEvent{Type: playsource.PlaybackEvent_VOLUME_CHANGED, Volume: 50, Muted: true}
+	assert.Equal(t, []*playsource.PlaybackEvent{expected}, volumeChanged())
+
+	// Fading changes the mixer, but not the volume.
+	m.fader.lock.Lock()
+	m.fader.target, m.fader.gain = 50, 40
+	m.fader.lock.Unlock()
+	mixer = 20
+	assert.Empty(t, volumeChanged())
+}
+
+func TestSkipSongFade(t *testing.T) {
+	tracklist := &fakeTracklist{volume: 80}
+	server := httptest.NewServer(tracklist)
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	client := mopidy.NewClient(server.URL)
+	m := &MopidyServer{
+		client:    client,
+		events:    newEventLog(clock),
+		skipVoter: newSkipVoter(SkipVoteConfig{}, clock),
+		settings:  newPlaybackSettings(PlaybackConfig{SkipFade: 10 * time.Second}),
+		fader:     newFader(clock, client.GetVolume, client.SetVolume),
+		clock:     clock,
+	}
+
+	skip := func() chan *playsource.SkipSongResponse {
+		done := make(chan *playsource.SkipSongResponse)
+		go func() {
+			resp, err := m.SkipSong(context.Background(), &playsource.SkipSongRequest{})
+			assert.NoError(t, err)
+			done <- resp
+		}()
+		return done
+	}
+
+	// The fade can't outlast the song, so with 100ms left, it takes
+	// two steps, and then the volume is restored.
+	tracklist.set(&mopidy.TlTrack{TLID: 1, Track: mopidy.Track{Length: 1000}})
+	tracklist.lock.Lock()
+	tracklist.position = 900
+	tracklist.lock.Unlock()
+	done := skip()
+	clock.BlockUntil(2)
+	clock.Advance(fadeStep)
+	assert.True(t, (<-done).Skipped)
+	assert.Equal(t, 3, tracklist.called("core.mixer.set_volume"))
+	assert.Equal(t, 1, tracklist.called("core.playback.next"))
+
+	// If the song ends while fading out, the fade stops, and the song
+	// after it isn't skipped in its place.
+	tracklist.set(&mopidy.TlTrack{TLID: 2, Track: mopidy.Track{Length: 200000}})
+	tracklist.lock.Lock()
+	tracklist.position = 0
+	tracklist.lock.Unlock()
+	done = skip()
+	clock.BlockUntil(2)
+	tracklist.set(&mopidy.TlTrack{TLID: 3})
+	clock.Advance(crossfadePollInterval)
+	assert.True(t, (<-done).Skipped)
+	assert.Equal(t, 1, tracklist.called("core.playback.next"))
+}
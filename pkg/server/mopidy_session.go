@@ -1,9 +1,14 @@
 package server
 
 import (
+	"fmt"
 	"log"
+	"sort"
+	"sync"
 	"time"
 
+	"golang.org/x/net/context"
+
 	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
 	"github.com/crowdsoundsystem/playsource/pkg/playsource"
 )
@@ -11,105 +16,691 @@ import (
 type SongTrackPair struct {
 	Song  playsource.Song
 	Track mopidy.Track
+
+	// Tracklist id of the track, once it's been added to the tracklist.
+	TLID int
+}
+
+// FinishedSong is a SongTrackPair that has finished playing.
+type FinishedSong struct {
+	SongTrackPair
+
+	Started  time.Time
+	Finished time.Time
+
+	Skipped bool
+
+	// Whether the song couldn't be played, and why.
+	Failed bool
+	Error  string
+
+	// The request the song was skipped for, if it was.
+	skip *playsource.SkipSongRequest
+}
+
+// response reports the song on the QueueSong stream. Skipped songs are
+// reported as skipped rather than finished.
+func (s FinishedSong) response() *playsource.QueueSongResponse {
+	return &playsource.QueueSongResponse{
+		SongId:   s.Song.SongId,
+		Found:    true,
+		Finished: !s.Skipped,
+		Skipped:  s.Skipped,
+		Failed:   s.Failed,
+		Error:    s.Error,
+	}
+}
+
+// Songs that end having played less than this fraction of their length,
+// without being skipped, are considered to have failed. Mopidy skips over
+// tracks it can't stream, say due to an expired Spotify token.
+const failedPlayFraction = 0.1
+
+type bySongId []SongTrackPair
+
+func (s bySongId) Len() int           { return len(s) }
+func (s bySongId) Less(i, j int) bool { return s[i].Song.SongId < s[j].Song.SongId }
+func (s bySongId) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// queuedSong is a song in the session's queue.
+type queuedSong struct {
+	SongTrackPair
+
+	// Increases with each song queued, see reconcile().
+	seq    uint64
+	queued time.Time
+
+	// When we saw the song start playing, if we have.
+	started time.Time
+
+	// Where the song was when it last ended, if it has, in milliseconds.
+	ended  bool
+	played int
+
+	// The request the song is being skipped for, if it is.
+	skip *playsource.SkipSongRequest
+
+	// Whether the song is being removed from the tracklist, in which
+	// case it leaving isn't reported. See SetRemoving.
+	removing bool
+}
+
+func (s queuedSong) finished(at time.Time) FinishedSong {
+	song := FinishedSong{
+		SongTrackPair: s.SongTrackPair,
+		Started:       s.started,
+		Finished:      at,
+		Skipped:       s.skip != nil,
+		skip:          s.skip,
+	}
+
+	switch {
+	case s.started.IsZero():
+		song.Failed = true
+		song.Error = "Left the tracklist without playing"
+	case s.ended && s.skip == nil && s.played < int(failedPlayFraction*float64(s.Track.Length)):
+		song.Failed = true
+		song.Error = fmt.Sprintf("Ended after %vms of %vms", s.played, s.Track.Length)
+	}
+
+	return song
 }
 
 type MopidySession struct {
 	client       *mopidy.Client
+	events       *eventLog
 	pollInterval time.Duration
+	clock        Clock
+
+	// Cancelled when the session is closed, abandoning
+	// whatever requests the monitor is making.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Called with each song as it finishes.
+	onFinish func(FinishedSong)
+
+	// Called when the volume, or whether it's muted, may have changed.
+	onVolume func(context.Context)
 
-	shutdown chan struct{}
-	queue    chan SongTrackPair
-	finished chan SongTrackPair
+	// Songs that have been queued but not yet finished, in the same
+	// order as the tracklist. Songs are identified by their tlid, so we
+	// can map what mopidy is playing back to the crowdsound song, even
+	// if a track is queued more than once.
+	tracksLock sync.Mutex
+	queue      []queuedSong
+	seq        uint64
+
+	// The tlids in the tracklist when it was last reconciled, so
+	// that changes to it can be noticed while polling.
+	tlids []int
+
+	// Songs that have finished, but that the primary hasn't been told
+	// about, in the order they finished. finished is signalled when
+	// songs are added.
+	undelivered []FinishedSong
+	finished    chan struct{}
 }
 
-func NewMopidySession(client *mopidy.Client, queueSize int, pollInterval time.Duration) (*MopidySession, error) {
+// NewMopidySession resets mopidy, and starts a session. The context only
+// bounds resetting mopidy. onFinish is called with each song as it
+// finishes, whether or not the primary is there to be told, and onVolume
+// whenever the volume may have changed.
+func NewMopidySession(ctx context.Context, client *mopidy.Client, events *eventLog, pollInterval time.Duration, clock Clock, onFinish func(FinishedSong), onVolume func(context.Context)) (*MopidySession, error) {
 	// First, reset mopidy into a blank state.
-	if err := client.SetConsume(true); err != nil {
+	if err := client.SetConsume(ctx, true); err != nil {
 		return nil, err
 	}
 
-	if err := client.ClearTracklist(); err != nil {
+	if err := client.ClearTracklist(ctx); err != nil {
 		return nil, err
 	}
 
-	if err := client.Stop(); err != nil {
-		return nil, err
-	}
-
-	// Get initial history length
-	history, err := client.History()
-	if err != nil {
+	if err := client.Stop(ctx); err != nil {
 		return nil, err
 	}
 
 	session := &MopidySession{
 		client:       client,
+		events:       events,
 		pollInterval: pollInterval,
-		shutdown:     make(chan struct{}),
-		queue:        make(chan SongTrackPair, queueSize),
-		finished:     make(chan SongTrackPair, queueSize),
+		clock:        clock,
+		onFinish:     onFinish,
+		onVolume:     onVolume,
+		finished:     make(chan struct{}, 1),
 	}
+	session.ctx, session.cancel = context.WithCancel(context.Background())
 
-	go session.monitor(len(history))
+	go session.monitor()
 
 	return session, nil
 }
 
 func (m *MopidySession) Close() error {
-	close(m.shutdown)
+	m.cancel()
 	return nil
 }
 
+// QueueSong records a song that was appended to the tracklist. If it
+// was inserted elsewhere, the session must be synced with Sync.
 func (m *MopidySession) QueueSong(song SongTrackPair) error {
-	select {
-	case <-m.shutdown:
-		return nil
-	case m.queue <- song:
-		return nil
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	m.seq++
+	m.queue = append(m.queue, queuedSong{
+		SongTrackPair: song,
+		seq:           m.seq,
+		queued:        m.clock.Now(),
+	})
+
+	return nil
+}
+
+// index returns the position of tlid in the queue, or -1 if it
+// isn't queued. tracksLock must be held.
+func (m *MopidySession) index(tlid int) int {
+	for i, s := range m.queue {
+		if s.TLID == tlid {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Lookup returns the song that was queued as the tracklist entry tlid.
+func (m *MopidySession) Lookup(tlid int) (SongTrackPair, bool) {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	if i := m.index(tlid); i >= 0 {
+		return m.queue[i].SongTrackPair, true
+	}
+
+	return SongTrackPair{}, false
+}
+
+// Find returns the queued song with songID.
+func (m *MopidySession) Find(songID int32) (SongTrackPair, bool) {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	for _, s := range m.queue {
+		if s.Song.SongId == songID {
+			return s.SongTrackPair, true
+		}
+	}
+
+	return SongTrackPair{}, false
+}
+
+// SetRemoving marks the songs queued as the tracklist entries tlids as
+// being removed, or not, if removing them failed. Songs must be marked
+// before they're removed from the tracklist, so they aren't reported as
+// failed if the tracklist is reconciled before they're forgotten.
+func (m *MopidySession) SetRemoving(tlids []int, removing bool) {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	for _, tlid := range tlids {
+		if i := m.index(tlid); i >= 0 {
+			m.queue[i].removing = removing
+		}
+	}
+}
+
+// Remove forgets a queued song, so that it won't be reported as
+// finished. It must already have been removed from the tracklist.
+func (m *MopidySession) Remove(songID int32) bool {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	for i, s := range m.queue {
+		if s.Song.SongId == songID {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return true
+		}
 	}
+
+	return false
 }
 
-func (m *MopidySession) FinishedChan() <-chan SongTrackPair {
+// Sync orders the queue to match the tracklist, after it's been
+// rearranged. Songs that have left the tracklist stay at the front,
+// since they've played, but we haven't heard that they finished.
+func (m *MopidySession) Sync(tlTracks []mopidy.TlTrack) {
+	positions := make(map[int]int)
+	for i, t := range tlTracks {
+		positions[t.TLID] = i
+	}
+
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	sort.Stable(byTracklist{m.queue, positions})
+}
+
+type byTracklist struct {
+	songs     []queuedSong
+	positions map[int]int
+}
+
+func (s byTracklist) position(i int) int {
+	if p, ok := s.positions[s.songs[i].TLID]; ok {
+		return p
+	}
+
+	return -1
+}
+
+func (s byTracklist) Len() int           { return len(s.songs) }
+func (s byTracklist) Less(i, j int) bool { return s.position(i) < s.position(j) }
+func (s byTracklist) Swap(i, j int)      { s.songs[i], s.songs[j] = s.songs[j], s.songs[i] }
+
+// Finished is signalled when songs finish. They're delivered with
+// Undelivered and Delivered.
+func (m *MopidySession) Finished() <-chan struct{} {
 	return m.finished
 }
 
-func (m *MopidySession) monitor(initialHistoryCount int) {
-	// A song enters history once it starts playing, so the count starts at 1.
-	currentSize := initialHistoryCount + 1
-	for {
-		select {
-		case <-m.shutdown:
+// Undelivered returns the songs that have finished, but haven't been
+// delivered, in the order they finished.
+func (m *MopidySession) Undelivered() []FinishedSong {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	return append([]FinishedSong(nil), m.undelivered...)
+}
+
+// Delivered forgets a finished song, once the primary has been told.
+func (m *MopidySession) Delivered(song FinishedSong) {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	for i, s := range m.undelivered {
+		if s.TLID == song.TLID {
+			m.undelivered = append(m.undelivered[:i], m.undelivered[i+1:]...)
 			return
-		case <-time.After(m.pollInterval):
-			history, err := m.client.History()
-			if err != nil {
-				log.Println("Error retrieving history:", err)
+		}
+	}
+}
+
+// InFlight returns the songs that have been queued, but that the primary
+// hasn't been told have finished.
+func (m *MopidySession) InFlight() []SongTrackPair {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	songs := make([]SongTrackPair, 0, len(m.queue)+len(m.undelivered))
+	for _, s := range m.queue {
+		songs = append(songs, s.SongTrackPair)
+	}
+	for _, s := range m.undelivered {
+		songs = append(songs, s.SongTrackPair)
+	}
+
+	sort.Sort(bySongId(songs))
+	return songs
+}
+
+// markStarted records that tlid started playing at started, unless
+// we already knew. It returns the song, if tlid was queued by us,
+// and whether we didn't already know it started.
+func (m *MopidySession) markStarted(tlid int, started time.Time) (SongTrackPair, bool) {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	i := m.index(tlid)
+	if i < 0 || !m.queue[i].started.IsZero() {
+		return SongTrackPair{}, false
+	}
+
+	m.queue[i].started = started
+	return m.queue[i].SongTrackPair, true
+}
+
+// markEnded records that tlid ended at position, in milliseconds. Since it
+// must have played, it's marked as started, in case we missed that.
+func (m *MopidySession) markEnded(tlid int, position int) {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	i := m.index(tlid)
+	if i < 0 {
+		return
+	}
+
+	if m.queue[i].started.IsZero() {
+		m.queue[i].started = m.clock.Now().Add(-time.Duration(position) * time.Millisecond)
+	}
+
+	m.queue[i].ended = true
+	m.queue[i].played = position
+}
+
+// SetSkipped marks the queued song tlid as being skipped for skip, or not
+// if skip is nil, so that it ending early isn't mistaken for a failure. The
+// SKIPPED event is published when it ends, so that it comes before the
+// song's TRACK_FINISHED. It returns the song, if tlid was queued by us.
+func (m *MopidySession) SetSkipped(tlid int, skip *playsource.SkipSongRequest) (SongTrackPair, bool) {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	i := m.index(tlid)
+	if i < 0 {
+		return SongTrackPair{}, false
+	}
+
+	m.queue[i].skip = skip
+	return m.queue[i].SongTrackPair, true
+}
+
+// reconcile finishes the queued songs that have left the tracklist. Songs
+// we never saw start, and that aren't in mopidy's history, didn't play, say
+// because they were removed from the tracklist by someone else, or couldn't
+// be played. Neither did songs that ended far too early. It returns whether
+// the tracklist changed since it was last reconciled.
+func (m *MopidySession) reconcile() (changed bool) {
+	// Songs queued after we fetch the tracklist won't be in it yet.
+	m.tracksLock.Lock()
+	seq := m.seq
+	m.tracksLock.Unlock()
+
+	var tlTracks []mopidy.TlTrack
+	var current *mopidy.TlTrack
+
+	var batch mopidy.Batch
+	batch.TlTracks(&tlTracks)
+	batch.CurrentTlTrack(&current)
+	if err := m.client.Batch(m.ctx, &batch); err != nil {
+		log.Println("[session] Error getting tracklist:", err)
+		return false
+	}
+
+	tlids := make([]int, len(tlTracks))
+	inTracklist := make(map[int]bool)
+	for i, t := range tlTracks {
+		tlids[i] = t.TLID
+		inTracklist[t.TLID] = true
+	}
+	if current != nil {
+		inTracklist[current.TLID] = true
+	}
+
+	var left []queuedSong
+
+	m.tracksLock.Lock()
+	remaining := make([]queuedSong, 0, len(m.queue))
+	for _, s := range m.queue {
+		if s.seq > seq || inTracklist[s.TLID] || s.removing {
+			remaining = append(remaining, s)
+			continue
+		}
+
+		left = append(left, s)
+	}
+	m.queue = remaining
+	changed = !sameTLIDs(m.tlids, tlids)
+	m.tlids = tlids
+	m.tracksLock.Unlock()
+
+	m.findPlays(left)
+
+	now := m.clock.Now()
+	for _, s := range left {
+		m.finish(s.finished(now))
+	}
+
+	return changed
+}
+
+func sameTLIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// findPlays looks for the songs we never saw start in mopidy's history,
+// and marks them started if they played. Short songs can play entirely
+// between polls.
+func (m *MopidySession) findPlays(songs []queuedSong) {
+	var since time.Time
+	for _, s := range songs {
+		if s.started.IsZero() && (since.IsZero() || s.queued.Before(since)) {
+			since = s.queued
+		}
+	}
+
+	if since.IsZero() {
+		return
+	}
+
+	history, skipped, err := m.client.HistorySince(m.ctx, since)
+	if err != nil {
+		log.Println("[session] Error getting history:", err)
+		return
+	}
+	for _, err := range skipped {
+		log.Println("[session] Skipped:", err)
+	}
+
+	// The oldest plays go to the songs queued first, since the
+	// same track may be queued more than once.
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		for j := range songs {
+			s := &songs[j]
+			if !s.started.IsZero() || s.Track.URI != entry.TrackRef.URI {
 				continue
 			}
 
-			newSize := len(history)
-			if newSize < currentSize {
+			// Mopidy's timestamps only have millisecond precision.
+			if entry.Timestamp.Before(s.queued.Truncate(time.Millisecond)) {
 				continue
-			} else if newSize == currentSize {
-				state, err := m.client.CurrentState()
-				if err != nil {
-					log.Println("[session] Error getting state:", err)
-					continue
-				}
-
-				if state == mopidy.Stopped {
-					m.finished <- <-m.queue
-					currentSize++
-				}
+			}
+
+			s.started = entry.Timestamp
+			break
+		}
+	}
+}
+
+func (m *MopidySession) finish(song FinishedSong) {
+	// A closed session's songs are no one's concern.
+	if m.ctx.Err() != nil {
+		return
+	}
+
+	if song.skip != nil {
+		e := trackEvent(playsource.PlaybackEvent_SKIPPED, &song.Song, song.Track)
+		e.Reason = song.skip.Reason
+		e.Requester = song.skip.Requester
+		m.events.publish(e)
+	}
+
+	if song.Failed {
+		log.Printf("[session] %v failed: %v", song.Song, song.Error)
+
+		e := trackEvent(playsource.PlaybackEvent_TRACK_FAILED, &song.Song, song.Track)
+		e.Error = song.Error
+		m.events.publish(e)
+	} else {
+		m.events.publish(trackEvent(playsource.PlaybackEvent_TRACK_FINISHED, &song.Song, song.Track))
+	}
 
+	if m.onFinish != nil {
+		m.onFinish(song)
+	}
+
+	m.tracksLock.Lock()
+	m.undelivered = append(m.undelivered, song)
+	m.tracksLock.Unlock()
+
+	select {
+	case m.finished <- struct{}{}:
+	default:
+	}
+}
+
+// pollStarted checks whether the playing song has started, for when
+// we may have missed the event saying so (i.e. we're polling).
+func (m *MopidySession) pollStarted() {
+	var state mopidy.PlayState
+	var current *mopidy.TlTrack
+	var position int
+
+	// Polling makes these requests often, so they're made together.
+	var batch mopidy.Batch
+	batch.CurrentState(&state)
+	batch.CurrentTlTrack(&current)
+	batch.TimePosition(&position)
+	if err := m.client.Batch(m.ctx, &batch); err != nil {
+		log.Println("[session] Error getting playback state:", err)
+		return
+	}
+
+	if current == nil || state != mopidy.Playing {
+		return
+	}
+
+	started := m.clock.Now().Add(-time.Duration(position) * time.Millisecond)
+	if pair, ok := m.markStarted(current.TLID, started); ok {
+		m.events.publish(trackEvent(playsource.PlaybackEvent_TRACK_STARTED, &pair.Song, pair.Track))
+	}
+}
+
+// poll catches up on what's changed, for when we may have missed the
+// events saying so.
+func (m *MopidySession) poll() {
+	m.pollStarted()
+
+	if m.reconcile() {
+		m.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_QUEUE_CHANGED})
+	}
+
+	if m.onVolume != nil {
+		m.onVolume(m.ctx)
+	}
+}
+
+// monitor tracks playback using mopidy's core events. If we can't
+// subscribe to events, or the subscription drops, we fall back to
+// polling the tracklist until we can subscribe again.
+func (m *MopidySession) monitor() {
+	var stream *mopidy.EventStream
+	var events <-chan mopidy.Event
+
+	// Watchers are only told once per disconnection.
+	connected := true
+	disconnected := func() {
+		if connected {
+			m.events.publish(&playsource.PlaybackEvent{
+				Type: playsource.PlaybackEvent_BACKEND_DISCONNECTED,
+			})
+		}
+		connected = false
+	}
+
+	subscribe := func() {
+		var err error
+		stream, err = m.client.Subscribe(m.ctx)
+		if err != nil {
+			log.Println("[session] Unable to subscribe to events, polling:", err)
+			stream = nil
+			disconnected()
+			return
+		}
+
+		events = stream.Events()
+		connected = true
+
+		// We may have missed events while connecting.
+		m.poll()
+	}
+
+	subscribe()
+
+	for {
+		poll := m.clock.NewTimer(m.pollInterval)
+
+		select {
+		case <-m.ctx.Done():
+			poll.Stop()
+			if stream != nil {
+				stream.Close()
+			}
+			return
+		case e, ok := <-events:
+			poll.Stop()
+			if !ok {
+				log.Println("[session] Event stream closed, polling:", stream.Err())
+				stream, events = nil, nil
+				disconnected()
 				continue
 			}
 
-			for i := currentSize; i < newSize; i++ {
-				m.finished <- <-m.queue
+			m.handleEvent(e)
+		case <-poll.C():
+			if stream == nil {
+				m.poll()
+				subscribe()
 			}
+		}
+	}
+}
+
+func (m *MopidySession) handleEvent(e mopidy.Event) {
+	switch e.Type {
+	case mopidy.TrackPlaybackStarted:
+		if e.TlTrack == nil {
+			return
+		}
+
+		m.markStarted(e.TlTrack.TLID, m.clock.Now())
+
+		if pair, ok := m.Lookup(e.TlTrack.TLID); ok {
+			m.events.publish(trackEvent(playsource.PlaybackEvent_TRACK_STARTED, &pair.Song, pair.Track))
+		} else {
+			m.events.publish(trackEvent(playsource.PlaybackEvent_TRACK_STARTED, nil, e.TlTrack.Track))
+		}
+	case mopidy.TrackPlaybackEnded:
+		if e.TlTrack == nil {
+			return
+		}
 
-			currentSize = newSize
+		// Whether it finished, or was just stopped, depends
+		// on whether it left the tracklist.
+		m.markEnded(e.TlTrack.TLID, e.TimePosition)
+		m.reconcile()
+	case mopidy.PlaybackStateChanged:
+		var t playsource.PlaybackEvent_Type
+		switch {
+		case e.NewState == mopidy.Paused:
+			t = playsource.PlaybackEvent_PAUSED
+		case e.OldState == mopidy.Paused && e.NewState == mopidy.Playing:
+			t = playsource.PlaybackEvent_RESUMED
+		case e.NewState == mopidy.Stopped:
+			t = playsource.PlaybackEvent_STOPPED
+		}
+
+		if t != playsource.PlaybackEvent_UNKNOWN {
+			m.events.publish(&playsource.PlaybackEvent{Type: t, State: playState(e.NewState)})
+		}
+	case mopidy.TracklistChanged:
+		m.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_QUEUE_CHANGED})
+		m.reconcile()
+	case mopidy.VolumeChanged, mopidy.MuteChanged:
+		if m.onVolume != nil {
+			m.onVolume(m.ctx)
 		}
 	}
 }
@@ -0,0 +1,571 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTracklist answers the tracklist requests a session makes, and
+// the playback and mixer requests made around them.
+type fakeTracklist struct {
+	lock     sync.Mutex
+	tlTracks []mopidy.TlTrack
+	current  *mopidy.TlTrack
+	history  [][]interface{}
+	position int
+	volume   int
+
+	// The methods called, in order.
+	calls []string
+}
+
+func (f *fakeTracklist) set(current *mopidy.TlTrack, tlTracks ...mopidy.TlTrack) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.current = current
+	f.tlTracks = tlTracks
+}
+
+type fakeRequest struct {
+	ID     int    `json:"id"`
+	Method string `json:"method"`
+}
+
+func (f *fakeTracklist) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	// Batches are answered in reverse, as nothing promises they're in order.
+	var batch []fakeRequest
+	if err := json.Unmarshal(body, &batch); err == nil {
+		var responses []interface{}
+		for i := len(batch) - 1; i >= 0; i-- {
+			responses = append(responses, f.respond(batch[i]))
+		}
+
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+
+	var req fakeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(f.respond(req))
+}
+
+// called returns how many times method was called.
+func (f *fakeTracklist) called(method string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var n int
+	for _, c := range f.calls {
+		if c == method {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeTracklist) respond(req fakeRequest) interface{} {
+	f.calls = append(f.calls, req.Method)
+
+	var result interface{}
+	switch req.Method {
+	case "core.tracklist.get_tl_tracks":
+		result = f.tlTracks
+	case "core.playback.get_current_tl_track":
+		result = f.current
+	case "core.playback.get_time_position":
+		result = f.position
+	case "core.history.get_history":
+		result = f.history
+	case "core.mixer.get_volume":
+		result = f.volume
+	case "core.mixer.set_volume":
+		result = true
+	}
+
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"result":  result,
+	}
+}
+
+func testSession(client *mopidy.Client) *MopidySession {
+	m := &MopidySession{
+		client:   client,
+		events:   newEventLog(RealClock),
+		clock:    RealClock,
+		finished: make(chan struct{}, 1),
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	return m
+}
+
+// nextFinished delivers the song that finished first.
+func nextFinished(t *testing.T, m *MopidySession) FinishedSong {
+	songs := m.Undelivered()
+	require.NotEmpty(t, songs)
+
+	m.Delivered(songs[0])
+	return songs[0]
+}
+
+// queued returns the ids of the songs in m's queue, in order.
+func queued(m *MopidySession) []int32 {
+	m.tracksLock.Lock()
+	defer m.tracksLock.Unlock()
+
+	var ids []int32
+	for _, s := range m.queue {
+		ids = append(ids, s.Song.SongId)
+	}
+	return ids
+}
+
+func TestSessionQueueBookkeeping(t *testing.T) {
+	tracklist := &fakeTracklist{}
+	server := httptest.NewServer(tracklist)
+	defer server.Close()
+
+	// The same track is queued for every song, so songs
+	// can only be told apart by their tlid.
+	m := testSession(mopidy.NewClient(server.URL))
+	for id := int32(1); id <= 4; id++ {
+		m.QueueSong(SongTrackPair{
+			Song:  playsource.Song{SongId: id},
+			Track: mopidy.Track{URI: "spotify:track:same"},
+			TLID:  int(id),
+		})
+	}
+
+	// Removed songs are forgotten entirely.
+	assert.True(t, m.Remove(2))
+	assert.False(t, m.Remove(2))
+	_, ok := m.Lookup(2)
+	assert.False(t, ok)
+
+	// 1 has played, but we haven't heard it finish yet,
+	// and 4 was moved ahead of 3.
+	m.markStarted(1, time.Now())
+	m.Sync([]mopidy.TlTrack{{TLID: 4}, {TLID: 5}, {TLID: 3}})
+	assert.Equal(t, []int32{1, 4, 3}, queued(m))
+
+	tracklist.set(nil, mopidy.TlTrack{TLID: 4}, mopidy.TlTrack{TLID: 3})
+	m.reconcile()
+	song := nextFinished(t, m)
+	assert.Equal(t, int32(1), song.Song.SongId)
+	assert.False(t, song.Failed)
+
+	// 4 was stopped, so it's still in the tracklist, while 3
+	// was removed by someone else without ever playing.
+	m.markStarted(4, time.Now())
+	tracklist.set(&mopidy.TlTrack{TLID: 4}, mopidy.TlTrack{TLID: 4})
+	m.reconcile()
+	song = nextFinished(t, m)
+	assert.Equal(t, int32(3), song.Song.SongId)
+	assert.True(t, song.Failed)
+
+	assert.Equal(t, []int32{4}, queued(m))
+	assert.Empty(t, m.Undelivered())
+}
+
+func TestSessionShortPlays(t *testing.T) {
+	tracklist := &fakeTracklist{}
+	server := httptest.NewServer(tracklist)
+	defer server.Close()
+
+	m := testSession(mopidy.NewClient(server.URL))
+	for id := int32(1); id <= 2; id++ {
+		m.QueueSong(SongTrackPair{
+			Song:  playsource.Song{SongId: id},
+			Track: mopidy.Track{URI: "spotify:track:long", Length: 200000},
+			TLID:  int(id),
+		})
+	}
+
+	// Both end within a second, but only 2 was skipped.
+	m.SetSkipped(2, &playsource.SkipSongRequest{})
+	m.markEnded(1, 500)
+	m.markEnded(2, 800)
+	m.reconcile()
+
+	song := nextFinished(t, m)
+	assert.Equal(t, int32(1), song.Song.SongId)
+	assert.True(t, song.Failed)
+	assert.Equal(t, "Ended after 500ms of 200000ms", song.Error)
+
+	song = nextFinished(t, m)
+	assert.Equal(t, int32(2), song.Song.SongId)
+	assert.False(t, song.Failed)
+	assert.True(t, song.Skipped)
+}
+
+func TestSessionUnseenPlays(t *testing.T) {
+	tracklist := &fakeTracklist{}
+	server := httptest.NewServer(tracklist)
+	defer server.Close()
+
+	clock := NewFakeClock(time.Unix(1500000000, 0))
+	m := testSession(mopidy.NewClient(server.URL))
+	m.clock = clock
+
+	// Both songs are the same track, and both left the
+	// tracklist between polls, but only one of them played.
+	for id := int32(1); id <= 2; id++ {
+		m.QueueSong(SongTrackPair{
+			Song:  playsource.Song{SongId: id},
+			Track: mopidy.Track{URI: "local:track:short", Length: 2000},
+			TLID:  int(id),
+		})
+	}
+
+	played := clock.Now().Add(time.Second)
+	tracklist.history = [][]interface{}{
+		{played.UnixNano() / int64(time.Millisecond), map[string]string{"uri": "local:track:short"}},
+		{played.Add(-time.Hour).UnixNano() / int64(time.Millisecond), map[string]string{"uri": "local:track:short"}},
+	}
+	clock.Advance(5 * time.Second)
+	m.reconcile()
+
+	song := nextFinished(t, m)
+	assert.Equal(t, int32(1), song.Song.SongId)
+	assert.False(t, song.Failed)
+	assert.Equal(t, played.Unix(), song.Started.Unix())
+
+	song = nextFinished(t, m)
+	assert.Equal(t, int32(2), song.Song.SongId)
+	assert.True(t, song.Failed)
+}
+
+func TestSessionRemoving(t *testing.T) {
+	tracklist := &fakeTracklist{}
+	server := httptest.NewServer(tracklist)
+	defer server.Close()
+
+	m := testSession(mopidy.NewClient(server.URL))
+	for id := int32(1); id <= 2; id++ {
+		m.QueueSong(SongTrackPair{
+			Song:  playsource.Song{SongId: id},
+			Track: mopidy.Track{URI: "spotify:track:same"},
+			TLID:  int(id),
+		})
+	}
+
+	// 1 is being removed, so it isn't reported when it leaves the
+	// tracklist before it's forgotten.
+	m.SetRemoving([]int{1}, true)
+	tracklist.set(nil, mopidy.TlTrack{TLID: 2})
+	m.reconcile()
+	assert.Empty(t, m.Undelivered())
+	assert.Equal(t, []int32{1, 2}, queued(m))
+
+	assert.True(t, m.Remove(1))
+	assert.Equal(t, []int32{2}, queued(m))
+
+	// If removing it fails, it's reported as usual once it leaves.
+	m.SetRemoving([]int{2}, true)
+	m.SetRemoving([]int{2}, false)
+	tracklist.set(nil)
+	m.reconcile()
+	song := nextFinished(t, m)
+	assert.Equal(t, int32(2), song.Song.SongId)
+}
+
+func TestSessionUndelivered(t *testing.T) {
+	tracklist := &fakeTracklist{}
+	server := httptest.NewServer(tracklist)
+	defer server.Close()
+
+	var recorded []int32
+	m := testSession(mopidy.NewClient(server.URL))
+	m.onFinish = func(song FinishedSong) { recorded = append(recorded, song.Song.SongId) }
+	for id := int32(1); id <= 2; id++ {
+		m.QueueSong(SongTrackPair{
+			Song:  playsource.Song{SongId: id},
+			Track: mopidy.Track{URI: "spotify:track:same"},
+			TLID:  int(id),
+		})
+	}
+
+	// 1 finishes with no one to tell, but it's accounted for
+	// right away, and it's still in flight until it's delivered.
+	m.markStarted(1, time.Now())
+	tracklist.set(nil, mopidy.TlTrack{TLID: 2})
+	m.reconcile()
+	assert.Equal(t, []int32{1}, recorded)
+	assert.Len(t, m.Finished(), 1)
+
+	inFlight := func() []int32 {
+		var ids []int32
+		for _, s := range m.InFlight() {
+			ids = append(ids, s.Song.SongId)
+		}
+		return ids
+	}
+	assert.Equal(t, []int32{1, 2}, inFlight())
+
+	assert.Equal(t, int32(1), nextFinished(t, m).Song.SongId)
+	assert.Equal(t, []int32{2}, inFlight())
+}
+
+// nextEvents returns the events published since it was last called.
+func nextEvents(c chan *playsource.PlaybackEvent) []*playsource.PlaybackEvent {
+	var events []*playsource.PlaybackEvent
+	for {
+		select {
+		case e := <-c:
+			e.Sequence, e.TimestampMs, e.Epoch = 0, 0, ""
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestSessionEvents(t *testing.T) {
+	tracklist := &fakeTracklist{}
+	server := httptest.NewServer(tracklist)
+	defer server.Close()
+
+	track := mopidy.Track{URI: "local:track:a", Length: 200000}
+	song := playsource.Song{SongId: 1}
+	other := mopidy.Track{URI: "local:track:other"}
+
+	m := testSession(mopidy.NewClient(server.URL))
+	m.QueueSong(SongTrackPair{Song: song, Track: track, TLID: 1})
+	tracklist.set(nil, mopidy.TlTrack{TLID: 1, Track: track})
+
+	_, c := m.events.watch("", 0)
+
+	// Events are handled in order, so each builds on the last.
+	for _, test := range []struct {
+		name     string
+		event    mopidy.Event
+		expected []*playsource.PlaybackEvent
+	}{
+		{
+			name:  "start without a track",
+			event: mopidy.Event{Type: mopidy.TrackPlaybackStarted},
+		},
+		{
+			name:     "start of a queued song",
+			event:    mopidy.Event{Type: mopidy.TrackPlaybackStarted, TlTrack: &mopidy.TlTrack{TLID: 1, Track: track}},
+			expected: []*playsource.PlaybackEvent{trackEvent(playsource.PlaybackEvent_TRACK_STARTED, &song, track)},
+		},
+		{
+			name:     "start of someone else's track",
+			event:    mopidy.Event{Type: mopidy.TrackPlaybackStarted, TlTrack: &mopidy.TlTrack{TLID: 9, Track: other}},
+			expected: []*playsource.PlaybackEvent{trackEvent(playsource.PlaybackEvent_TRACK_STARTED, nil, other)},
+		},
+		{
+			name:  "pause",
+			event: mopidy.Event{Type: mopidy.PlaybackStateChanged, OldState: mopidy.Playing, NewState: mopidy.Paused},
+			expected: []*playsource.PlaybackEvent{
+				{Type: playsource.PlaybackEvent_PAUSED, State: playsource.PlayState_PAUSED},
+			},
+		},
+		{
+			name:  "resume",
+			event: mopidy.Event{Type: mopidy.PlaybackStateChanged, OldState: mopidy.Paused, NewState: mopidy.Playing},
+			expected: []*playsource.PlaybackEvent{
+				{Type: playsource.PlaybackEvent_RESUMED, State: playsource.PlayState_PLAYING},
+			},
+		},
+		{
+			name:  "stop",
+			event: mopidy.Event{Type: mopidy.PlaybackStateChanged, OldState: mopidy.Playing, NewState: mopidy.Stopped},
+			expected: []*playsource.PlaybackEvent{
+				{Type: playsource.PlaybackEvent_STOPPED, State: playsource.PlayState_STOPPED},
+			},
+		},
+		{
+			// Playing from stopped is announced by the track starting.
+			name:  "play",
+			event: mopidy.Event{Type: mopidy.PlaybackStateChanged, OldState: mopidy.Stopped, NewState: mopidy.Playing},
+		},
+		{
+			name:     "tracklist change",
+			event:    mopidy.Event{Type: mopidy.TracklistChanged},
+			expected: []*playsource.PlaybackEvent{{Type: playsource.PlaybackEvent_QUEUE_CHANGED}},
+		},
+		{
+			// It's still in the tracklist, so it was only stopped.
+			name:  "end of a stopped song",
+			event: mopidy.Event{Type: mopidy.TrackPlaybackEnded, TlTrack: &mopidy.TlTrack{TLID: 1, Track: track}, TimePosition: 1000},
+		},
+		{
+			name:  "unknown event",
+			event: mopidy.Event{Type: "options_changed"},
+		},
+	} {
+		m.handleEvent(test.event)
+		assert.Equal(t, test.expected, nextEvents(c), test.name)
+	}
+
+	// Once it leaves the tracklist, it's finished.
+	tracklist.set(nil)
+	m.handleEvent(mopidy.Event{Type: mopidy.TrackPlaybackEnded, TlTrack: &mopidy.TlTrack{TLID: 1, Track: track}, TimePosition: 200000})
+	assert.Equal(t, []*playsource.PlaybackEvent{
+		trackEvent(playsource.PlaybackEvent_TRACK_FINISHED, &song, track),
+	}, nextEvents(c))
+}
+
+func TestSessionSkippedEvents(t *testing.T) {
+	tracklist := &fakeTracklist{}
+	server := httptest.NewServer(tracklist)
+	defer server.Close()
+
+	track := mopidy.Track{URI: "local:track:a", Length: 200000}
+	song := playsource.Song{SongId: 1}
+
+	m := testSession(mopidy.NewClient(server.URL))
+	m.QueueSong(SongTrackPair{Song: song, Track: track, TLID: 1})
+
+	_, c := m.events.watch("", 0)
+
+	// Nothing is said until it actually ends.
+	_, ok := m.SetSkipped(1, &playsource.SkipSongRequest{Reason: "boring", Requester: "alice"})
+	require.True(t, ok)
+	assert.Empty(t, nextEvents(c))
+
+	_, ok = m.SetSkipped(9, &playsource.SkipSongRequest{})
+	assert.False(t, ok)
+
+	// It's reported as skipped before it's reported as finished.
+	m.markEnded(1, 1000)
+	m.reconcile()
+
+	skipped := trackEvent(playsource.PlaybackEvent_SKIPPED, &song, track)
+	skipped.Reason = "boring"
+	skipped.Requester = "alice"
+	assert.Equal(t, []*playsource.PlaybackEvent{
+		skipped,
+		trackEvent(playsource.PlaybackEvent_TRACK_FINISHED, &song, track),
+	}, nextEvents(c))
+}
+
+func TestSessionReconnect(t *testing.T) {
+	tracklist := &fakeTracklist{}
+
+	// Subscriptions fail until up, and each one is handed to the test.
+	var up bool
+	var upLock sync.Mutex
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mopidy/ws" {
+			tracklist.ServeHTTP(w, r)
+			return
+		}
+
+		upLock.Lock()
+		defer upLock.Unlock()
+		if !up {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+			conns <- conn
+		}
+	}))
+	defer server.Close()
+
+	track := mopidy.Track{URI: "local:track:a"}
+	clock := NewFakeClock(time.Unix(1500000000, 0))
+	m := testSession(mopidy.NewClient(server.URL + "/mopidy/rpc"))
+	m.clock = clock
+	m.pollInterval = time.Second
+	m.QueueSong(SongTrackPair{Song: playsource.Song{SongId: 1}, Track: track, TLID: 1})
+	tracklist.set(nil, mopidy.TlTrack{TLID: 1, Track: track})
+
+	volumes := make(chan struct{}, 16)
+	m.onVolume = func(context.Context) { volumes <- struct{}{} }
+	volumeChecked := func() {
+		select {
+		case <-volumes:
+		case <-time.After(5 * time.Second):
+			t.Fatal("volume wasn't checked")
+		}
+	}
+
+	_, c := m.events.watch("", 0)
+	next := func() *playsource.PlaybackEvent {
+		select {
+		case e := <-c:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event published")
+			return nil
+		}
+	}
+
+	go m.monitor()
+	defer m.Close()
+
+	// Until we can subscribe, we poll, and are disconnected.
+	assert.Equal(t, playsource.PlaybackEvent_BACKEND_DISCONNECTED, next().Type)
+
+	// Polls notice the tracklist changing, once, and check the volume.
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(m.pollInterval)
+		volumeChecked()
+	}
+	assert.Equal(t, playsource.PlaybackEvent_QUEUE_CHANGED, next().Type)
+
+	upLock.Lock()
+	up = true
+	upLock.Unlock()
+
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(m.pollInterval)
+		conn := <-conns
+
+		// Events flow once we're subscribed.
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{
+			"event":    mopidy.TrackPlaybackStarted,
+			"tl_track": map[string]interface{}{"tlid": 1, "track": map[string]string{"uri": track.URI}},
+		}))
+		e := next()
+		assert.Equal(t, playsource.PlaybackEvent_TRACK_STARTED, e.Type)
+		assert.Equal(t, int32(1), e.Song.SongId)
+
+		// The mixer says when the volume changes.
+		for len(volumes) > 0 {
+			<-volumes
+		}
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"event": mopidy.VolumeChanged, "volume": 50}))
+		volumeChecked()
+
+		// Dropping the subscription disconnects us again.
+		conn.Close()
+		assert.Equal(t, playsource.PlaybackEvent_BACKEND_DISCONNECTED, next().Type)
+	}
+}
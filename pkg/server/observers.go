@@ -0,0 +1,90 @@
+package server
+
+import (
+	"io"
+	"log"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+// broadcaster fans out the primary's QueueSongResponses to observers.
+type broadcaster struct {
+	lock      sync.Mutex
+	observers map[chan *playsource.QueueSongResponse]struct{}
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{
+		observers: make(map[chan *playsource.QueueSongResponse]struct{}),
+	}
+}
+
+func (b *broadcaster) subscribe() chan *playsource.QueueSongResponse {
+	c := make(chan *playsource.QueueSongResponse, 64)
+
+	b.lock.Lock()
+	b.observers[c] = struct{}{}
+	b.lock.Unlock()
+
+	return c
+}
+
+func (b *broadcaster) unsubscribe(c chan *playsource.QueueSongResponse) {
+	b.lock.Lock()
+	delete(b.observers, c)
+	b.lock.Unlock()
+}
+
+// broadcast sends resp to every observer. Observers that aren't
+// keeping up miss responses, rather than holding up the primary.
+func (b *broadcaster) broadcast(resp *playsource.QueueSongResponse) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for c := range b.observers {
+		select {
+		case c <- resp:
+		default:
+			log.Println("Observer is falling behind, dropping response")
+		}
+	}
+}
+
+// observe serves a read-only QueueSong stream.
+func observe(stream playsource.Playsource_QueueSongServer, inbound <-chan playsource.QueueSongRequest, lease *LeaseManager, b *broadcaster) error {
+	responses := b.subscribe()
+	defer b.unsubscribe(responses)
+
+	holder, _ := lease.Holder()
+	err := stream.Send(&playsource.QueueSongResponse{
+		Lease: &playsource.Lease{Holder: holder},
+	})
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case req, ok := <-inbound:
+			if !ok {
+				return nil
+			}
+
+			if req.Song != nil {
+				return errf(codes.PermissionDenied, "Observers may not queue songs")
+			}
+		case resp := <-responses:
+			err := stream.Send(resp)
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"log"
+
+	"golang.org/x/net/context"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+// Resolution is the result of searching for a song.
+type Resolution struct {
+	Song   playsource.Song
+	Tracks []mopidy.Track
+	Err    error
+}
+
+// Resolver searches for songs in the background, so that slow
+// searches (i.e. spotify) don't hold up the caller. At most
+// parallelism searches are performed at once. If cache is nil,
+// nothing is cached.
+type Resolver struct {
+	client *mopidy.Client
+	cache  *SearchCache
+	sem    chan struct{}
+}
+
+func NewResolver(client *mopidy.Client, cache *SearchCache, parallelism int) *Resolver {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	return &Resolver{
+		client: client,
+		cache:  cache,
+		sem:    make(chan struct{}, parallelism),
+	}
+}
+
+// Resolve searches for song in the background, giving up if ctx is
+// done first. The returned channel receives exactly one Resolution.
+func (r *Resolver) Resolve(ctx context.Context, song playsource.Song) <-chan Resolution {
+	result := make(chan Resolution, 1)
+
+	go func() {
+		tracks, err := r.search(ctx, song)
+		result <- Resolution{
+			Song:   song,
+			Tracks: tracks,
+			Err:    err,
+		}
+	}()
+
+	return result
+}
+
+func (r *Resolver) search(ctx context.Context, song playsource.Song) ([]mopidy.Track, error) {
+	if r.cache != nil {
+		if tracks, ok := r.cache.Get(song); ok {
+			return tracks, nil
+		}
+	}
+
+	select {
+	case r.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-r.sem }()
+
+	args := mopidy.SearchArgs{
+		TrackName: []string{song.Name},
+		Artist:    song.Artists,
+	}
+
+	searchResults, err := r.client.Search(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]mopidy.Track, 0)
+	for _, sr := range searchResults {
+		tracks = append(tracks, sr.Tracks...)
+	}
+
+	if r.cache != nil {
+		if err := r.cache.Put(song, tracks); err != nil {
+			log.Println("Error caching search results:", err)
+		}
+	}
+
+	return tracks, nil
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+func TestResolver(t *testing.T) {
+	var searches, failing int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fakeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "core.library.search", req.Method)
+		atomic.AddInt32(&searches, 1)
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if atomic.LoadInt32(&failing) != 0 {
+			resp["error"] = map[string]interface{}{"code": 0, "message": "Application error"}
+		} else {
+			resp["result"] = []mopidy.SearchResult{
+				{Tracks: []mopidy.Track{testTrack("local:track:song", "Song", "Artist")}},
+				{Tracks: []mopidy.Track{testTrack("spotify:track:song", "Song", "Artist")}},
+			}
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	r := NewResolver(mopidy.NewClient(server.URL), NewSearchCache(time.Hour, clock), 1)
+	ctx := context.Background()
+
+	// Every backend's results are candidates.
+	song := playsource.Song{SongId: 1, Name: "Song", Artists: []string{"Artist"}}
+	res := <-r.Resolve(ctx, song)
+	require.NoError(t, res.Err)
+	assert.Equal(t, song, res.Song)
+	assert.Len(t, res.Tracks, 2)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&searches))
+
+	// The same song is only searched for once, until the results expire.
+	again := playsource.Song{SongId: 2, Name: "song", Artists: []string{"ARTIST"}}
+	res = <-r.Resolve(ctx, again)
+	require.NoError(t, res.Err)
+	assert.Equal(t, again, res.Song)
+	assert.Len(t, res.Tracks, 2)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&searches))
+
+	clock.Advance(time.Hour)
+	<-r.Resolve(ctx, song)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&searches))
+
+	// Failed searches aren't cached.
+	other := playsource.Song{SongId: 3, Name: "Other", Artists: []string{"Artist"}}
+	atomic.StoreInt32(&failing, 1)
+	res = <-r.Resolve(ctx, other)
+	assert.IsType(t, &mopidy.BackendError{}, res.Err)
+
+	atomic.StoreInt32(&failing, 0)
+	res = <-r.Resolve(ctx, other)
+	assert.NoError(t, res.Err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&searches))
+
+	// Songs waiting for a search to finish give up with their context.
+	r.sem <- struct{}{}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	res = <-r.Resolve(cancelled, playsource.Song{SongId: 4, Name: "Uncached"})
+	assert.Equal(t, context.Canceled, res.Err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&searches))
+	<-r.sem
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+func searchArgs(req *playsource.SearchRequest) (args mopidy.SearchArgs, err error) {
+	if req.TrackName != "" {
+		args.TrackName = []string{req.TrackName}
+	}
+	if req.Genre != "" {
+		args.Genre = []string{req.Genre}
+	}
+	if req.Album != "" {
+		args.Album = []string{req.Album}
+	}
+	if req.Any != "" {
+		args.Any = []string{req.Any}
+	}
+	args.Artist = req.Artists
+
+	if len(args.TrackName) == 0 && len(args.Artist) == 0 && len(args.Genre) == 0 &&
+		len(args.Album) == 0 && len(args.Any) == 0 {
+		return args, errf(codes.InvalidArgument, "No search fields specified")
+	}
+
+	if req.Offset < 0 || req.Limit < 0 {
+		return args, errf(codes.InvalidArgument, "Offset and limit must not be negative")
+	}
+
+	return args, nil
+}
+
+// searchResponse builds the page of tracks requested by req.
+func searchResponse(req *playsource.SearchRequest, tracks []mopidy.Track) *playsource.SearchResponse {
+	resp := &playsource.SearchResponse{
+		Tracks: make([]*playsource.Track, 0),
+		Total:  int32(len(tracks)),
+	}
+
+	start := int(req.Offset)
+	if start > len(tracks) {
+		start = len(tracks)
+	}
+
+	end := len(tracks)
+	if req.Limit > 0 && start+int(req.Limit) < end {
+		end = start + int(req.Limit)
+	}
+
+	for _, t := range tracks[start:end] {
+		resp.Tracks = append(resp.Tracks, trackInfo(t))
+	}
+
+	return resp
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+var searchBucket = []byte("search")
+
+// The most entries a cache holds, in memory or on disk. Once it's full,
+// expired entries are evicted, then those closest to expiring, until
+// it's back down to pruneFraction of the limit.
+const (
+	maxCacheEntries = 10000
+	pruneFraction   = 0.9
+)
+
+// DefaultSearchCacheTTL is how long servers cache search results
+// for if they aren't given a cache.
+const DefaultSearchCacheTTL = time.Hour
+
+type cacheEntry struct {
+	Tracks  []mopidy.Track `json:"tracks"`
+	Expires time.Time      `json:"expires"`
+}
+
+// SearchCache caches the search results for songs. If backed by a
+// database, results also survive restarts.
+type SearchCache struct {
+	ttl        time.Duration
+	clock      Clock
+	maxEntries int
+
+	lock    sync.Mutex
+	entries map[string]cacheEntry
+
+	// Expired entries are swept out by the first Put after nextSweep.
+	nextSweep time.Time
+
+	// Optional. stored is how many entries db holds, which is only
+	// used in its write transactions, so bolt serializes its use.
+	db     *bolt.DB
+	stored int
+}
+
+// NewSearchCache returns an in memory SearchCache.
+func NewSearchCache(ttl time.Duration, clock Clock) *SearchCache {
+	return &SearchCache{
+		ttl:        ttl,
+		clock:      clock,
+		maxEntries: maxCacheEntries,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+// OpenSearchCache returns a SearchCache that is persisted at path. Entries
+// that expired while it was closed are deleted.
+func OpenSearchCache(path string, ttl time.Duration, clock Clock) (*SearchCache, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewSearchCache(ttl, clock)
+	c.db = db
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(searchBucket)
+		if err != nil {
+			return err
+		}
+
+		return c.pruneBucket(b, c.maxEntries)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// cacheKey identifies a song by its name and artists, ignoring
+// case, punctuation, and the order of the artists.
+func cacheKey(song playsource.Song) string {
+	artists := make([]string, len(song.Artists))
+	for i, a := range song.Artists {
+		artists[i] = normalize(a)
+	}
+	sort.Strings(artists)
+
+	return normalize(song.Name) + "\x00" + strings.Join(artists, "\x00")
+}
+
+func (c *SearchCache) Get(song playsource.Song) (tracks []mopidy.Track, ok bool) {
+	key := cacheKey(song)
+	now := c.clock.Now()
+
+	c.lock.Lock()
+	entry, ok := c.entries[key]
+	if ok && !now.Before(entry.Expires) {
+		delete(c.entries, key)
+	}
+	c.lock.Unlock()
+
+	if ok && now.Before(entry.Expires) {
+		return entry.Tracks, true
+	}
+
+	if c.db == nil {
+		return nil, false
+	}
+
+	var value []byte
+	c.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(searchBucket).Get([]byte(key)); v != nil {
+			value = append([]byte{}, v...)
+		}
+		return nil
+	})
+	if value == nil {
+		return nil, false
+	}
+
+	if err := json.Unmarshal(value, &entry); err != nil || !now.Before(entry.Expires) {
+		// It's of no use to anyone, so it's deleted.
+		c.db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(searchBucket)
+			if b.Get([]byte(key)) == nil {
+				return nil
+			}
+
+			c.stored--
+			return b.Delete([]byte(key))
+		})
+		return nil, false
+	}
+
+	c.lock.Lock()
+	c.add(key, entry, now, false)
+	c.lock.Unlock()
+
+	return entry.Tracks, true
+}
+
+func (c *SearchCache) Put(song playsource.Song, tracks []mopidy.Track) error {
+	key := cacheKey(song)
+	now := c.clock.Now()
+	entry := cacheEntry{
+		Tracks:  tracks,
+		Expires: now.Add(c.ttl),
+	}
+
+	c.lock.Lock()
+	sweep := !now.Before(c.nextSweep)
+	if sweep {
+		c.nextSweep = now.Add(c.ttl)
+	}
+	c.add(key, entry, now, sweep)
+	c.lock.Unlock()
+
+	if c.db == nil {
+		return nil
+	}
+
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(searchBucket)
+		if b.Get([]byte(key)) == nil {
+			c.stored++
+		}
+		if err := b.Put([]byte(key), value); err != nil {
+			return err
+		}
+
+		if c.stored > c.maxEntries {
+			return c.pruneBucket(b, int(pruneFraction*float64(c.maxEntries)))
+		} else if sweep {
+			return c.pruneBucket(b, c.maxEntries)
+		}
+
+		return nil
+	})
+}
+
+// add caches entry in memory, making room for it if the cache is full,
+// and sweeping out expired entries if sweep is set. lock must be held.
+func (c *SearchCache) add(key string, entry cacheEntry, now time.Time, sweep bool) {
+	c.entries[key] = entry
+
+	max := c.maxEntries
+	if len(c.entries) > max {
+		max = int(pruneFraction * float64(c.maxEntries))
+	} else if !sweep {
+		return
+	}
+
+	expires := make(map[string]time.Time, len(c.entries))
+	for k, e := range c.entries {
+		expires[k] = e.Expires
+	}
+
+	for _, k := range evictions(expires, now, max) {
+		delete(c.entries, k)
+	}
+}
+
+// pruneBucket deletes the expired entries from b, and then those closest
+// to expiring, until it holds at most max. Entries that can't be decoded
+// are deleted too.
+func (c *SearchCache) pruneBucket(b *bolt.Bucket, max int) error {
+	now := c.clock.Now()
+	expires := make(map[string]time.Time)
+
+	err := b.ForEach(func(k, v []byte) error {
+		var entry cacheEntry
+		if json.Unmarshal(v, &entry) == nil {
+			expires[string(k)] = entry.Expires
+		} else {
+			expires[string(k)] = time.Time{}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	evicted := evictions(expires, now, max)
+	for _, k := range evicted {
+		if err := b.Delete([]byte(k)); err != nil {
+			return err
+		}
+	}
+
+	c.stored = len(expires) - len(evicted)
+	return nil
+}
+
+// evictions returns the keys to evict so that at most max of them are
+// left: those that have expired by now, and then those closest to expiring.
+func evictions(expires map[string]time.Time, now time.Time, max int) []string {
+	keys := make([]string, 0, len(expires))
+	for k := range expires {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return expires[keys[i]].Before(expires[keys[j]]) })
+
+	n := 0
+	for n < len(keys) && (len(keys)-n > max || !now.Before(expires[keys[n]])) {
+		n++
+	}
+
+	return keys[:n]
+}
+
+func (c *SearchCache) Close() error {
+	if c.db == nil {
+		return nil
+	}
+
+	return c.db.Close()
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+func TestSearchCache(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := NewSearchCache(time.Hour, clock)
+
+	song := playsource.Song{Name: "Shivers", Artists: []string{"Armin van Buuren", "Nadia Ali"}}
+	tracks := []mopidy.Track{testTrack("local:track:shivers", "Shivers", "Armin van Buuren")}
+
+	_, ok := c.Get(song)
+	assert.False(t, ok)
+	require.NoError(t, c.Put(song, tracks))
+
+	// Songs are the same regardless of case, punctuation,
+	// and the order of the artists.
+	cached, ok := c.Get(playsource.Song{Name: "shivers!", Artists: []string{"NADIA ALI", "Armin van Buuren"}})
+	assert.True(t, ok)
+	assert.Equal(t, tracks, cached)
+
+	_, ok = c.Get(playsource.Song{Name: "Shivers", Artists: []string{"Ed Sheeran"}})
+	assert.False(t, ok)
+
+	// Results expire after the TTL.
+	clock.Advance(time.Hour - time.Second)
+	_, ok = c.Get(song)
+	assert.True(t, ok)
+	clock.Advance(time.Second)
+	_, ok = c.Get(song)
+	assert.False(t, ok)
+	assert.Empty(t, c.entries)
+}
+
+func TestSearchCachePersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "search_cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "search.db")
+	clock := NewFakeClock(time.Now())
+	song := playsource.Song{Name: "Shivers", Artists: []string{"Armin van Buuren"}}
+	tracks := []mopidy.Track{testTrack("local:track:shivers", "Shivers", "Armin van Buuren")}
+
+	c, err := OpenSearchCache(path, time.Hour, clock)
+	require.NoError(t, err)
+	require.NoError(t, c.Put(song, tracks))
+	require.NoError(t, c.Close())
+
+	// Results survive a restart, but still expire.
+	c, err = OpenSearchCache(path, time.Hour, clock)
+	require.NoError(t, err)
+	defer c.Close()
+
+	cached, ok := c.Get(song)
+	assert.True(t, ok)
+	assert.Equal(t, tracks, cached)
+
+	// Once they've expired, they're deleted.
+	clock.Advance(time.Hour)
+	_, ok = c.Get(song)
+	assert.False(t, ok)
+	assert.Equal(t, 0, diskEntries(t, c))
+}
+
+// diskEntries returns how many entries c has in its database.
+func diskEntries(t *testing.T, c *SearchCache) int {
+	var n int
+	require.NoError(t, c.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(searchBucket).ForEach(func(k, v []byte) error {
+			n++
+			return nil
+		})
+	}))
+	return n
+}
+
+func TestSearchCacheEviction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "search_cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clock := NewFakeClock(time.Now())
+	c, err := OpenSearchCache(filepath.Join(dir, "search.db"), time.Hour, clock)
+	require.NoError(t, err)
+	defer c.Close()
+	c.maxEntries = 10
+
+	song := func(i int) playsource.Song {
+		return playsource.Song{Name: fmt.Sprintf("Song %v", i)}
+	}
+
+	// Once full, the entries closest to expiring are evicted, until
+	// there's some room.
+	for i := 0; i <= 10; i++ {
+		require.NoError(t, c.Put(song(i), nil))
+		clock.Advance(time.Second)
+	}
+	assert.Len(t, c.entries, 9)
+	assert.Equal(t, 9, diskEntries(t, c))
+	for i := 0; i <= 10; i++ {
+		_, ok := c.Get(song(i))
+		assert.Equal(t, i >= 2, ok, "song %v", i)
+	}
+
+	// Expired entries are swept out as new ones are added.
+	clock.Advance(time.Hour)
+	require.NoError(t, c.Put(song(11), nil))
+	assert.Len(t, c.entries, 1)
+	assert.Equal(t, 1, diskEntries(t, c))
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+func TestSearchArgs(t *testing.T) {
+	args, err := searchArgs(&playsource.SearchRequest{
+		TrackName: "Shivers",
+		Artists:   []string{"Armin van Buuren", "Nadia Ali"},
+		Album:     "Shivers",
+		Offset:    10,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mopidy.SearchArgs{
+		TrackName: []string{"Shivers"},
+		Artist:    []string{"Armin van Buuren", "Nadia Ali"},
+		Album:     []string{"Shivers"},
+	}, args)
+
+	for name, req := range map[string]*playsource.SearchRequest{
+		"no fields":       {Offset: 10},
+		"negative offset": {Any: "Shivers", Offset: -1},
+		"negative limit":  {Any: "Shivers", Limit: -1},
+	} {
+		_, err := searchArgs(req)
+		assert.Equal(t, codes.InvalidArgument, grpc.Code(err), name)
+	}
+}
+
+func TestSearchResponse(t *testing.T) {
+	var tracks []mopidy.Track
+	for _, uri := range []string{"a", "b", "c", "d", "e"} {
+		tracks = append(tracks, mopidy.Track{URI: uri})
+	}
+
+	for _, test := range []struct {
+		offset, limit int32
+		uris          []string
+	}{
+		// No limit means the rest of the results.
+		{0, 0, []string{"a", "b", "c", "d", "e"}},
+		{2, 0, []string{"c", "d", "e"}},
+		{0, 2, []string{"a", "b"}},
+		{2, 2, []string{"c", "d"}},
+		{4, 2, []string{"e"}},
+		{0, 10, []string{"a", "b", "c", "d", "e"}},
+		{5, 2, nil},
+		{10, 2, nil},
+	} {
+		resp := searchResponse(&playsource.SearchRequest{Offset: test.offset, Limit: test.limit}, tracks)
+
+		var uris []string
+		for _, track := range resp.Tracks {
+			uris = append(uris, track.Uri)
+		}
+		assert.Equal(t, test.uris, uris, "offset %v, limit %v", test.offset, test.limit)
+
+		// The total is of every result, not just the page.
+		assert.Equal(t, int32(5), resp.Total)
+		assert.NotNil(t, resp.Tracks)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	// Each backend answers with its own result.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fakeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": []interface{}{
+			map[string]interface{}{"tracks": []mopidy.Track{{URI: "local:track:a"}, {URI: "local:track:b"}}},
+			map[string]interface{}{"tracks": nil},
+			map[string]interface{}{"tracks": []mopidy.Track{{URI: "spotify:track:c"}}},
+		}})
+	}))
+	defer server.Close()
+
+	m := &MopidyServer{client: mopidy.NewClient(server.URL)}
+
+	// Pages run across backends, in the order mopidy listed them.
+	resp, err := m.Search(context.Background(), &playsource.SearchRequest{Any: "Shivers", Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), resp.Total)
+	require.Len(t, resp.Tracks, 2)
+	assert.Equal(t, "local:track:b", resp.Tracks[0].Uri)
+	assert.Equal(t, "spotify:track:c", resp.Tracks[1].Uri)
+
+	// Searches for nothing are rejected.
+	_, err = m.Search(context.Background(), &playsource.SearchRequest{})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+}
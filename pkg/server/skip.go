@@ -0,0 +1,88 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+// SkipVoteConfig configures how listeners vote to skip songs.
+type SkipVoteConfig struct {
+	// Distinct requesters needed to skip a song. If 0, skips aren't
+	// voted on, and songs are skipped as soon as anyone asks.
+	Threshold int
+
+	// Votes expire after Window.
+	Window time.Duration
+}
+
+var DefaultSkipVoteConfig = SkipVoteConfig{
+	Window: 1 * time.Minute,
+}
+
+// checkExpected returns an error if req expects a song other than
+// playing, which is nil if the playing song wasn't queued by us.
+func checkExpected(req *playsource.SkipSongRequest, playing *playsource.Song) error {
+	if req.Expected == nil {
+		return nil
+	}
+
+	if playing == nil || playing.SongId != req.Expected.SongId {
+		return errf(codes.FailedPrecondition, "Song %v is not playing", req.Expected.SongId)
+	}
+
+	return nil
+}
+
+// skipVoter decides when the playing song should be skipped. Votes only
+// count towards the song they were cast for.
+type skipVoter struct {
+	config SkipVoteConfig
+
+	lock  sync.Mutex
+	song  int
+	votes *votes
+}
+
+func newSkipVoter(config SkipVoteConfig, clock Clock) *skipVoter {
+	return &skipVoter{
+		config: config,
+		votes:  newVotes(config.Window, clock),
+	}
+}
+
+// vote casts req's vote to skip the song identified by song. The response
+// says whether the song should be skipped now.
+func (s *skipVoter) vote(req *playsource.SkipSongRequest, song int) (*playsource.SkipSongResponse, error) {
+	if s.config.Threshold == 0 {
+		return &playsource.SkipSongResponse{Skipped: true}, nil
+	}
+
+	if req.Requester == "" {
+		return nil, errf(codes.InvalidArgument, "Requester must be specified")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Votes for the previous song don't carry over.
+	if song != s.song {
+		s.song = song
+		s.votes.reset()
+	}
+
+	resp := &playsource.SkipSongResponse{
+		Votes:     int32(s.votes.vote(req.Requester, 1)),
+		Threshold: int32(s.config.Threshold),
+	}
+
+	if resp.Votes >= resp.Threshold {
+		s.votes.reset()
+		resp.Skipped = true
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipVoter(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	v := newSkipVoter(SkipVoteConfig{Threshold: 2, Window: time.Minute}, clock)
+
+	_, err := v.vote(&playsource.SkipSongRequest{}, 1)
+	assert.Error(t, err)
+
+	// Voting twice doesn't count twice.
+	for i := 0; i < 2; i++ {
+		resp, err := v.vote(&playsource.SkipSongRequest{Requester: "a"}, 1)
+		assert.NoError(t, err)
+		assert.False(t, resp.Skipped)
+		assert.Equal(t, int32(1), resp.Votes)
+		assert.Equal(t, int32(2), resp.Threshold)
+	}
+
+	// Votes expire.
+	clock.Advance(time.Minute)
+	resp, err := v.vote(&playsource.SkipSongRequest{Requester: "b"}, 1)
+	assert.NoError(t, err)
+	assert.False(t, resp.Skipped)
+	assert.Equal(t, int32(1), resp.Votes)
+
+	// Votes for the previous song are discarded.
+	resp, err = v.vote(&playsource.SkipSongRequest{Requester: "b"}, 2)
+	assert.NoError(t, err)
+	assert.False(t, resp.Skipped)
+	assert.Equal(t, int32(1), resp.Votes)
+
+	resp, err = v.vote(&playsource.SkipSongRequest{Requester: "a"}, 2)
+	assert.NoError(t, err)
+	assert.True(t, resp.Skipped)
+	assert.Equal(t, int32(2), resp.Votes)
+
+	// Without a threshold, anyone can skip.
+	v = newSkipVoter(SkipVoteConfig{}, RealClock)
+	resp, err = v.vote(&playsource.SkipSongRequest{}, 1)
+	assert.NoError(t, err)
+	assert.True(t, resp.Skipped)
+}
+
+func TestCheckExpected(t *testing.T) {
+	playing := &playsource.Song{SongId: 1}
+
+	assert.NoError(t, checkExpected(&playsource.SkipSongRequest{}, nil))
+	assert.NoError(t, checkExpected(&playsource.SkipSongRequest{Expected: playing}, playing))
+
+	// A stale skip doesn't skip the next song.
+	stale := &playsource.SkipSongRequest{Expected: &playsource.Song{SongId: 0}}
+	assert.Error(t, checkExpected(stale, playing))
+	assert.Error(t, checkExpected(stale, nil))
+}
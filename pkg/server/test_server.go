@@ -1,6 +1,7 @@
 package server
 
 import (
+	"hash/fnv"
 	"io"
 	"log"
 	"math/rand"
@@ -10,68 +11,387 @@ import (
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 
+	"github.com/crowdsoundsystem/playsource/pkg/history"
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
 	"github.com/crowdsoundsystem/playsource/pkg/playsource"
 )
 
 var errf = grpc.Errorf // Used to stop `go vet` errors for grpc.Errof
 
+// TestConfig configures a TestServer.
+type TestConfig struct {
+	MaxQueueSize int
+
+	// Track lengths are normally distributed around SongLength, with a
+	// standard deviation of SongLengthStdDev. A track is always the same
+	// length, and none are shorter than a tenth of SongLength.
+	SongLength       time.Duration
+	SongLengthStdDev time.Duration
+
+	// The faults injected until they're changed with SetFaults.
+	Faults playsource.Faults
+
+	// Defaults to DefaultLeaseTimeout if not positive.
+	LeaseTimeout time.Duration
+
+	// Defaults to DefaultVolumeVoteConfig.
+	VolumeVote VolumeVoteConfig
+
+	// Skips aren't voted on by default. The window
+	// defaults to DefaultSkipVoteConfig's.
+	SkipVote SkipVoteConfig
+
+	// Defaults to RealClock. With a FakeClock, songs
+	// only play as fast as the clock is advanced.
+	Clock Clock
+}
+
 // TestServer provides the semantics of an actual playsource, without
-// actually requiring one.
+// actually requiring one. It also serves TestControl, so tests can
+// inject the faults an actual playsource runs into.
 type TestServer struct {
 	maxQueueSize     int
 	songLength       time.Duration
-	foundProbability float64
+	songLengthStdDev time.Duration
+
+	faults *faults
+	clock  Clock
 
+	// The position is as of positionAt, since it
+	// changes on its own while playing.
 	nowPlayingLock sync.Mutex
 	nowPlaying     playsource.Song
+	state          playsource.PlayState
+	position       time.Duration
+	positionAt     time.Time
+
+	volumeLock  sync.Mutex
+	volume      int
+	muted       bool
+	volumeVoter *volumeVoter
+	skipVoter   *skipVoter
+
+	// Songs waiting to be played. The player is
+	// signalled on queued when songs are added.
+	queueLock sync.Mutex
+	queue     []playsource.Song
+	queued    chan struct{}
 
-	master    chan struct{}
-	queue     chan playsource.Song
-	finished  chan playsource.Song
+	control   chan playbackCommand
 	shutdown  chan struct{}
 	queueSize int32
 
-	historyLock sync.Mutex
-	history     []playsource.Song
+	// Like MopidyServer, only the lease holder may queue songs,
+	// and controlLock is held by whichever stream is doing so.
+	lease       *LeaseManager
+	observers   *broadcaster
+	controlLock sync.Mutex
+
+	// Like MopidyServer's, the session outlives the primary's stream.
+	// Songs that finish are kept until they're delivered, and the
+	// primary is signalled on finished.
+	sessionLock sync.Mutex
+	sessionID   string
+	undelivered []FinishedSong
+	finished    chan struct{}
+
+	history  *history.MemoryStore
+	events   *eventLog
+	settings *playbackSettings
 }
 
-func NewTestServer(maxQueueSize int, foundProbability float64, songLength time.Duration) *TestServer {
+func NewTestServer(config TestConfig) *TestServer {
+	if config.Clock == nil {
+		config.Clock = RealClock
+	}
+
 	t := &TestServer{
-		maxQueueSize:     maxQueueSize,
-		foundProbability: foundProbability,
-		songLength:       songLength,
-		master:           make(chan struct{}, 1),
-		queue:            make(chan playsource.Song, maxQueueSize),
-		finished:         make(chan playsource.Song, maxQueueSize),
+		maxQueueSize:     config.MaxQueueSize,
+		songLength:       config.SongLength,
+		songLengthStdDev: config.SongLengthStdDev,
+		faults:           newFaults(config.Faults, config.Clock),
+		clock:            config.Clock,
+		queued:           make(chan struct{}, 1),
+		finished:         make(chan struct{}, 1),
+		control:          make(chan playbackCommand),
+		shutdown:         make(chan struct{}),
+		lease:            NewLeaseManager(config.LeaseTimeout, config.Clock),
+		observers:        newBroadcaster(),
+		history:          history.NewMemoryStore(),
+		events:           newEventLog(config.Clock),
+		settings:         newPlaybackSettings(PlaybackConfig{}),
+		state:            playsource.PlayState_STOPPED,
+		volume:           100,
+	}
+
+	if config.VolumeVote == (VolumeVoteConfig{}) {
+		config.VolumeVote = DefaultVolumeVoteConfig
 	}
+	t.volumeVoter = newVolumeVoter(config.VolumeVote, config.Clock, t.getVolume, t.setVolume)
 
-	t.master <- struct{}{}
+	if config.SkipVote.Window == 0 {
+		config.SkipVote.Window = DefaultSkipVoteConfig.Window
+	}
+	t.skipVoter = newSkipVoter(config.SkipVote, config.Clock)
 
 	// Launch queue processor
-	go func() {
-		for {
-			select {
-			case <-t.shutdown:
+	go t.run()
+
+	return t
+}
+
+type playbackAction int
+
+const (
+	pauseAction playbackAction = iota
+	resumeAction
+	stopAction
+	seekAction
+	previousAction
+	skipAction
+
+	// Stops the playing song without it finishing,
+	// when a new session is started.
+	resetAction
+)
+
+type playbackCommand struct {
+	action   playbackAction
+	position time.Duration
+
+	// Why, and by whom, a song is skipped.
+	reason    string
+	requester string
+}
+
+func (t *TestServer) run() {
+	stopped := true
+	for {
+		if song, ok := t.dequeue(); ok {
+			var played bool
+			if stopped, played = t.play(song); !played {
 				return
-			case song := <-t.queue:
-				t.nowPlayingLock.Lock()
-				t.nowPlaying = song
-				t.nowPlayingLock.Unlock()
+			}
+			continue
+		}
 
-				time.Sleep(t.songLength)
-				atomic.AddInt32(&t.queueSize, -1)
+		// Like mopidy, playback stops once the queue runs out.
+		if !stopped {
+			stopped = true
+			t.events.publish(&playsource.PlaybackEvent{
+				Type:  playsource.PlaybackEvent_STOPPED,
+				State: playsource.PlayState_STOPPED,
+			})
+		}
+
+		select {
+		case <-t.shutdown:
+			return
+		case <-t.control:
+			// Nothing is playing, so there's nothing to control.
+		case <-t.queued:
+		}
+	}
+}
 
-				t.historyLock.Lock()
-				t.history = append(t.history, song)
-				t.historyLock.Unlock()
+// enqueue queues req's song where it asks to be.
+func (t *TestServer) enqueue(req playsource.QueueSongRequest) {
+	// Positions include the playing song.
+	offset := 0
+	if _, ok := t.playing(); ok {
+		offset = 1
+	}
 
-				t.finished <- song
+	t.queueLock.Lock()
+	position := len(t.queue)
+	switch req.Insertion {
+	case playsource.QueueSongRequest_PLAY_NEXT:
+		position = 0
+	case playsource.QueueSongRequest_AT_POSITION:
+		if p := int(req.Position) - offset; p < 0 {
+			position = 0
+		} else if p < len(t.queue) {
+			position = p
+		}
+	}
+	t.queue = append(t.queue[:position], append([]playsource.Song{*req.Song}, t.queue[position:]...)...)
+	t.queueLock.Unlock()
+
+	select {
+	case t.queued <- struct{}{}:
+	default:
+	}
+
+	t.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_QUEUE_CHANGED})
+}
+
+func (t *TestServer) dequeue() (playsource.Song, bool) {
+	t.queueLock.Lock()
+	defer t.queueLock.Unlock()
+
+	if len(t.queue) == 0 {
+		return playsource.Song{}, false
+	}
+
+	song := t.queue[0]
+	t.queue = t.queue[1:]
+	return song, true
+}
+
+// play plays song until it has finished, returning whether playback
+// was stopped when it did, and false if the server was closed first.
+func (t *TestServer) play(song playsource.Song) (stopped bool, played bool) {
+	if t.faults.fails(song) {
+		return false, t.fail(song, "Simulated playback failure")
+	}
+
+	length := t.length(song.Name)
+	started := t.clock.Now()
+	state := playsource.PlayState_PLAYING
+	position := time.Duration(0)
+	skipped := false
+	t.setPlaying(song, state, position)
+	t.events.publish(t.trackEvent(playsource.PlaybackEvent_TRACK_STARTED, song))
+
+playback:
+	for {
+		var finished <-chan time.Time
+		var timer Timer
+		if state == playsource.PlayState_PLAYING {
+			timer = t.clock.NewTimer(length - position)
+			finished = timer.C()
+		}
+
+		resumed := t.clock.Now()
+		select {
+		case <-t.shutdown:
+			return false, false
+		case <-finished:
+			break playback
+		case cmd := <-t.control:
+			if timer != nil {
+				timer.Stop()
+			}
+
+			if state == playsource.PlayState_PLAYING {
+				position += t.clock.Now().Sub(resumed)
 			}
+
+			switch cmd.action {
+			case pauseAction:
+				if state == playsource.PlayState_PLAYING {
+					state = playsource.PlayState_PAUSED
+					t.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_PAUSED, State: state})
+				}
+			case resumeAction:
+				paused := state == playsource.PlayState_PAUSED
+				state = playsource.PlayState_PLAYING
+				if paused {
+					t.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_RESUMED, State: state})
+				}
+			case stopAction:
+				if state != playsource.PlayState_STOPPED {
+					t.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_STOPPED, State: playsource.PlayState_STOPPED})
+				}
+				state = playsource.PlayState_STOPPED
+				position = 0
+			case seekAction:
+				position = cmd.position
+				if position > length {
+					position = length
+				}
+			case previousAction:
+				// There's nothing before the current song,
+				// so it starts over.
+				position = 0
+			case skipAction:
+				e := t.trackEvent(playsource.PlaybackEvent_SKIPPED, song)
+				e.Reason = cmd.reason
+				e.Requester = cmd.requester
+				t.events.publish(e)
+
+				skipped = true
+				break playback
+			case resetAction:
+				if state != playsource.PlayState_STOPPED {
+					t.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_STOPPED, State: playsource.PlayState_STOPPED})
+				}
+
+				t.setPlaying(playsource.Song{}, playsource.PlayState_STOPPED, 0)
+				return true, true
+			}
+
+			t.setPlaying(song, state, position)
 		}
-	}()
+	}
 
-	return t
+	t.setPlaying(playsource.Song{}, playsource.PlayState_STOPPED, 0)
+	atomic.AddInt32(&t.queueSize, -1)
+
+	t.history.Record(history.Entry{
+		Song:     song,
+		Started:  started,
+		Finished: t.clock.Now(),
+		Skipped:  skipped,
+	})
+
+	t.events.publish(t.trackEvent(playsource.PlaybackEvent_TRACK_FINISHED, song))
+	t.finish(FinishedSong{
+		SongTrackPair: SongTrackPair{Song: song},
+		Started:       started,
+		Finished:      t.clock.Now(),
+		Skipped:       skipped,
+	})
+	return state == playsource.PlayState_STOPPED, true
+}
+
+// fail reports that song couldn't be played.
+func (t *TestServer) fail(song playsource.Song, reason string) bool {
+	atomic.AddInt32(&t.queueSize, -1)
+
+	e := t.trackEvent(playsource.PlaybackEvent_TRACK_FAILED, song)
+	e.Error = reason
+	t.events.publish(e)
+
+	t.finish(FinishedSong{
+		SongTrackPair: SongTrackPair{Song: song},
+		Finished:      t.clock.Now(),
+		Failed:        true,
+		Error:         reason,
+	})
+	return true
+}
+
+// finish keeps song until it's delivered to the primary, signalling
+// the primary if it's connected.
+func (t *TestServer) finish(song FinishedSong) {
+	t.sessionLock.Lock()
+	t.undelivered = append(t.undelivered, song)
+	t.sessionLock.Unlock()
+
+	select {
+	case t.finished <- struct{}{}:
+	default:
+	}
+}
+
+func (t *TestServer) setPlaying(song playsource.Song, state playsource.PlayState, position time.Duration) {
+	t.nowPlayingLock.Lock()
+	defer t.nowPlayingLock.Unlock()
+
+	t.nowPlaying = song
+	t.state = state
+	t.position = position
+	t.positionAt = t.clock.Now()
+}
+
+func (t *TestServer) command(cmd playbackCommand) {
+	select {
+	case <-t.shutdown:
+	case t.control <- cmd:
+	}
 }
 
 func (t *TestServer) Close() error {
@@ -80,8 +400,71 @@ func (t *TestServer) Close() error {
 }
 
 func (t *TestServer) QueueSong(stream playsource.Playsource_QueueSongServer) error {
+	if err := t.faults.inject(); err != nil {
+		return err
+	}
+
 	inbound := queueStream(stream)
 
+	// The first request identifies the controller.
+	first, ok := <-inbound
+	if !ok {
+		return nil
+	}
+
+	handshake := first.Handshake
+	if handshake == nil {
+		handshake = &playsource.Handshake{}
+	}
+
+	if handshake.Observe {
+		return observe(stream, inbound, t.lease, t.observers)
+	}
+
+	token, revoked, err := t.lease.Acquire(handshake.Controller, handshake.Takeover)
+	if err != nil {
+		return errf(codes.Unavailable, "A primary already exists: %v", err)
+	}
+	defer t.lease.Release(token)
+
+	t.controlLock.Lock()
+	defer t.controlLock.Unlock()
+
+	info, err := t.attach(handshake.SessionId)
+	if err != nil {
+		return errf(codes.Internal, "Couldn't start session: %v", err)
+	}
+
+	if first.Handshake != nil {
+		err := stream.Send(&playsource.QueueSongResponse{
+			Lease: &playsource.Lease{
+				Holder: handshake.Controller,
+				Token:  token,
+			},
+			Session: info,
+		})
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// Songs that finished since the last primary left are replayed.
+	if err := t.deliver(stream); err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if first.Song != nil {
+		if err := t.queueSong(stream, first); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
 	for {
 		select {
 		case req, ok := <-inbound:
@@ -89,46 +472,27 @@ func (t *TestServer) QueueSong(stream playsource.Playsource_QueueSongServer) err
 				return nil
 			}
 
-			// Perform lookup immediately
-			if t.foundProbability < rand.Float64() {
-				err := stream.Send(&playsource.QueueSongResponse{
-					SongId: req.Song.SongId,
-					Queued: false,
-					Found:  false,
-				})
-				if err == io.EOF {
-					return nil
-				} else if err != nil {
-					return err
-				}
+			if err := t.lease.Heartbeat(token); err != nil {
+				return errf(codes.Aborted, "Lease was taken over")
+			}
 
+			// Requests without a song are just heartbeats.
+			if req.Song == nil {
 				continue
 			}
 
-			// Check queue size.
-			if int(atomic.LoadInt32(&t.queueSize)) >= t.maxQueueSize {
-				log.Println("Exceeded queue")
-				err := stream.Send(&playsource.QueueSongResponse{
-					SongId: req.Song.SongId,
-					Queued: false,
-					Found:  true,
-				})
-				if err == io.EOF {
-					return nil
-				} else if err != nil {
-					return err
-				}
+			if err := t.queueSong(stream, req); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
 			}
-
-			// All good, go for the queue
-			t.queue <- *req.Song
-		case song := <-t.finished:
-			log.Println("Sending back")
-			err := stream.Send(&playsource.QueueSongResponse{
-				SongId:   song.SongId,
-				Finished: true,
-				Found:    true,
-			})
+		case <-revoked:
+			holder, _ := t.lease.Holder()
+			return errf(codes.Aborted, "Lease was taken over by %q", holder)
+		case <-t.shutdown:
+			return errf(codes.Unavailable, "Server is shutting down")
+		case <-t.finished:
+			err := t.deliver(stream)
 			if err == io.EOF {
 				return nil
 			} else if err != nil {
@@ -138,33 +502,583 @@ func (t *TestServer) QueueSong(stream playsource.Playsource_QueueSongServer) err
 	}
 }
 
+// attach resumes the session identified by id, if it's the current
+// session. Otherwise, a new session is started, resetting playback.
+func (t *TestServer) attach(id string) (*playsource.Session, error) {
+	t.sessionLock.Lock()
+	resume := id != "" && id == t.sessionID
+	var finished []FinishedSong
+	if resume {
+		finished = append(finished, t.undelivered...)
+	}
+	t.sessionLock.Unlock()
+
+	if resume {
+		info := &playsource.Session{
+			Id:      id,
+			Resumed: true,
+		}
+
+		for _, s := range finished {
+			song := s.Song
+			info.InFlight = append(info.InFlight, &song)
+		}
+		if song, ok := t.playing(); ok {
+			info.InFlight = append(info.InFlight, &song)
+		}
+
+		t.queueLock.Lock()
+		for _, song := range t.queue {
+			song := song
+			info.InFlight = append(info.InFlight, &song)
+		}
+		t.queueLock.Unlock()
+
+		return info, nil
+	}
+
+	t.reset()
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	t.sessionLock.Lock()
+	t.sessionID = id
+	t.sessionLock.Unlock()
+
+	return &playsource.Session{Id: id}, nil
+}
+
+// reset stops playback and clears the queue, forgetting the songs
+// that finished without being delivered, like a new MopidySession.
+func (t *TestServer) reset() {
+	t.queueLock.Lock()
+	cleared := len(t.queue) > 0
+	t.queue = nil
+	t.queueLock.Unlock()
+
+	// Once the player has taken the command, the songs it
+	// finished beforehand are all undelivered.
+	t.command(playbackCommand{action: resetAction})
+
+	t.sessionLock.Lock()
+	t.undelivered = nil
+	t.sessionLock.Unlock()
+	atomic.StoreInt32(&t.queueSize, 0)
+
+	if cleared {
+		t.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_QUEUE_CHANGED})
+	}
+}
+
+// deliver sends the songs that have finished to the primary, only
+// forgetting them once they've been sent.
+func (t *TestServer) deliver(stream playsource.Playsource_QueueSongServer) error {
+	for {
+		t.sessionLock.Lock()
+		if len(t.undelivered) == 0 {
+			t.sessionLock.Unlock()
+			return nil
+		}
+		song := t.undelivered[0]
+		t.sessionLock.Unlock()
+
+		if err := t.send(stream, song.response()); err != nil {
+			return err
+		}
+
+		t.sessionLock.Lock()
+		t.undelivered = t.undelivered[1:]
+		t.sessionLock.Unlock()
+	}
+}
+
+// queueSong looks req's song up, and queues it if it's found and
+// there's room. Queued songs are responded to once they've finished.
+func (t *TestServer) queueSong(stream playsource.Playsource_QueueSongServer, req playsource.QueueSongRequest) error {
+	// Lookups are as slow as everything else.
+	t.clock.Sleep(time.Duration(t.faults.get().LatencyMs) * time.Millisecond)
+
+	if !t.faults.found() {
+		return t.send(stream, &playsource.QueueSongResponse{
+			SongId: req.Song.SongId,
+			Queued: false,
+			Found:  false,
+			Reason: playsource.QueueSongResponse_NOT_FOUND,
+		})
+	}
+
+	// Check queue size.
+	if int(atomic.LoadInt32(&t.queueSize)) >= t.maxQueueSize {
+		log.Println("Exceeded queue")
+		return t.send(stream, &playsource.QueueSongResponse{
+			SongId: req.Song.SongId,
+			Queued: false,
+			Found:  true,
+			Reason: playsource.QueueSongResponse_QUEUE_FULL,
+		})
+	}
+
+	// All good, go for the queue
+	atomic.AddInt32(&t.queueSize, 1)
+	t.enqueue(req)
+	return nil
+}
+
+// send sends resp to the primary, and all observers.
+func (t *TestServer) send(stream playsource.Playsource_QueueSongServer, resp *playsource.QueueSongResponse) error {
+	t.observers.broadcast(resp)
+	return stream.Send(resp)
+}
+
 func (t *TestServer) SkipSong(ctx context.Context, req *playsource.SkipSongRequest) (*playsource.SkipSongResponse, error) {
-	return &playsource.SkipSongResponse{}, nil
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	song, ok := t.playing()
+
+	var expected *playsource.Song
+	if ok {
+		expected = &song
+	}
+
+	if err := checkExpected(req, expected); err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return &playsource.SkipSongResponse{}, nil
+	}
+
+	resp, err := t.skipVoter.vote(req, int(song.SongId))
+	if err != nil || !resp.Skipped {
+		return resp, err
+	}
+
+	t.command(playbackCommand{
+		action:    skipAction,
+		reason:    req.Reason,
+		requester: req.Requester,
+	})
+	return resp, nil
 }
 
 func (t *TestServer) GetPlaying(ctx context.Context, req *playsource.GetPlayingRequest) (*playsource.GetPlayingResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
 	t.nowPlayingLock.Lock()
 	song := t.nowPlaying
+	state := t.state
+	position := t.position
+	if state == playsource.PlayState_PLAYING {
+		position += t.clock.Now().Sub(t.positionAt)
+	}
 	t.nowPlayingLock.Unlock()
 
-	return &playsource.GetPlayingResponse{Song: &song}, nil
+	resp := &playsource.GetPlayingResponse{
+		Song:       &song,
+		State:      state,
+		PositionMs: int32(position / time.Millisecond),
+	}
+	if song.Name != "" {
+		resp.LengthMs = int32(t.length(song.Name) / time.Millisecond)
+	}
+
+	return resp, nil
 }
 
-func (t *TestServer) GetPlayHistory(req *playsource.GetPlayHistoryRequest, stream playsource.Playsource_GetPlayHistoryServer) error {
-	t.historyLock.Lock()
-	history := make([]playsource.Song, len(t.history))
-	copy(history, t.history)
-	t.historyLock.Unlock()
+func (t *TestServer) Pause(ctx context.Context, req *playsource.PauseRequest) (*playsource.PauseResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	t.command(playbackCommand{action: pauseAction})
+	return &playsource.PauseResponse{}, nil
+}
+
+func (t *TestServer) Resume(ctx context.Context, req *playsource.ResumeRequest) (*playsource.ResumeResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	t.command(playbackCommand{action: resumeAction})
+	return &playsource.ResumeResponse{}, nil
+}
+
+func (t *TestServer) Stop(ctx context.Context, req *playsource.StopRequest) (*playsource.StopResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	t.command(playbackCommand{action: stopAction})
+	return &playsource.StopResponse{}, nil
+}
+
+func (t *TestServer) Seek(ctx context.Context, req *playsource.SeekRequest) (*playsource.SeekResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	if req.PositionMs < 0 {
+		return nil, errf(codes.InvalidArgument, "Position must not be negative")
+	}
+
+	t.command(playbackCommand{
+		action:   seekAction,
+		position: time.Duration(req.PositionMs) * time.Millisecond,
+	})
+	return &playsource.SeekResponse{}, nil
+}
+
+func (t *TestServer) Previous(ctx context.Context, req *playsource.PreviousRequest) (*playsource.PreviousResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	t.command(playbackCommand{action: previousAction})
+	return &playsource.PreviousResponse{}, nil
+}
+
+// playing returns the song that's playing (or paused), if any.
+func (t *TestServer) playing() (playsource.Song, bool) {
+	t.nowPlayingLock.Lock()
+	defer t.nowPlayingLock.Unlock()
+
+	return t.nowPlaying, t.nowPlaying.Name != ""
+}
+
+func (t *TestServer) ListQueue(ctx context.Context, req *playsource.ListQueueRequest) (*playsource.ListQueueResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
 
-	for i := range history {
-		resp := &playsource.GetPlayHistoryResponse{
-			Song: &history[i],
+	resp := &playsource.ListQueueResponse{}
+	if song, ok := t.playing(); ok {
+		resp.Entries = append(resp.Entries, &playsource.QueueEntry{
+			Song:    &song,
+			Track:   t.track(song),
+			Playing: true,
+		})
+	}
+
+	t.queueLock.Lock()
+	for _, song := range t.queue {
+		song := song
+		resp.Entries = append(resp.Entries, &playsource.QueueEntry{
+			Song:  &song,
+			Track: t.track(song),
+		})
+	}
+	t.queueLock.Unlock()
+
+	return resp, nil
+}
+
+func (t *TestServer) RemoveFromQueue(ctx context.Context, req *playsource.RemoveFromQueueRequest) (*playsource.RemoveFromQueueResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	if err := checkPrimary(t.lease, req.LeaseToken); err != nil {
+		return nil, err
+	}
+
+	if song, ok := t.playing(); ok && song.SongId == req.SongId {
+		return nil, errf(codes.FailedPrecondition, "Song %v is playing, skip it instead", req.SongId)
+	}
+
+	t.queueLock.Lock()
+	defer t.queueLock.Unlock()
+
+	for i, song := range t.queue {
+		if song.SongId != req.SongId {
+			continue
 		}
 
-		if err := stream.Send(resp); err != nil {
-			return err
+		t.queue = append(t.queue[:i], t.queue[i+1:]...)
+		atomic.AddInt32(&t.queueSize, -1)
+		t.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_QUEUE_CHANGED})
+
+		return &playsource.RemoveFromQueueResponse{}, nil
+	}
+
+	return nil, errf(codes.NotFound, "Song %v is not queued", req.SongId)
+}
+
+func (t *TestServer) MoveInQueue(ctx context.Context, req *playsource.MoveInQueueRequest) (*playsource.MoveInQueueResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	if err := checkPrimary(t.lease, req.LeaseToken); err != nil {
+		return nil, err
+	}
+
+	// Positions include the playing song, which can't be moved.
+	offset := 0
+	if song, ok := t.playing(); ok {
+		if song.SongId == req.SongId {
+			return nil, errf(codes.FailedPrecondition, "Song %v is playing", req.SongId)
+		}
+		offset = 1
+	}
+
+	t.queueLock.Lock()
+	defer t.queueLock.Unlock()
+
+	from := -1
+	for i, song := range t.queue {
+		if song.SongId == req.SongId {
+			from = i
+			break
 		}
 	}
 
+	if from < 0 {
+		return nil, errf(codes.NotFound, "Song %v is not queued", req.SongId)
+	}
+
+	to := int(req.Position) - offset
+	if req.Position < 0 || to >= len(t.queue) {
+		return nil, errf(codes.InvalidArgument, "Position must be between 0 and %v", len(t.queue)+offset-1)
+	} else if to < 0 {
+		return nil, errf(codes.InvalidArgument, "Songs can't be moved before the playing song")
+	}
+
+	song := t.queue[from]
+	t.queue = append(t.queue[:from], t.queue[from+1:]...)
+	t.queue = append(t.queue[:to], append([]playsource.Song{song}, t.queue[to:]...)...)
+	t.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_QUEUE_CHANGED})
+
+	return &playsource.MoveInQueueResponse{}, nil
+}
+
+func (t *TestServer) ClearQueue(ctx context.Context, req *playsource.ClearQueueRequest) (*playsource.ClearQueueResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	if err := checkPrimary(t.lease, req.LeaseToken); err != nil {
+		return nil, err
+	}
+
+	t.queueLock.Lock()
+	removed := len(t.queue)
+	t.queue = nil
+	t.queueLock.Unlock()
+
+	if removed > 0 {
+		atomic.AddInt32(&t.queueSize, -int32(removed))
+		t.events.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_QUEUE_CHANGED})
+	}
+
+	return &playsource.ClearQueueResponse{Removed: int32(removed)}, nil
+}
+
+func (t *TestServer) getVolume(ctx context.Context) (int, error) {
+	t.volumeLock.Lock()
+	defer t.volumeLock.Unlock()
+
+	return t.volume, nil
+}
+
+func (t *TestServer) setVolume(ctx context.Context, volume int) error {
+	t.volumeLock.Lock()
+	defer t.volumeLock.Unlock()
+
+	t.volume = volume
 	return nil
 }
+
+func (t *TestServer) GetVolume(ctx context.Context, req *playsource.GetVolumeRequest) (*playsource.GetVolumeResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	t.volumeLock.Lock()
+	defer t.volumeLock.Unlock()
+
+	return &playsource.GetVolumeResponse{
+		Volume: int32(t.volume),
+		Muted:  t.muted,
+	}, nil
+}
+
+func (t *TestServer) SetVolume(ctx context.Context, req *playsource.SetVolumeRequest) (*playsource.SetVolumeResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	if err := validateVolume(req.Volume); err != nil {
+		return nil, err
+	}
+
+	t.setVolume(ctx, int(req.Volume))
+	t.volumeChanged()
+	return &playsource.SetVolumeResponse{}, nil
+}
+
+func (t *TestServer) SetMute(ctx context.Context, req *playsource.SetMuteRequest) (*playsource.SetMuteResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	t.volumeLock.Lock()
+	t.muted = req.Muted
+	t.volumeLock.Unlock()
+
+	t.volumeChanged()
+
+	return &playsource.SetMuteResponse{}, nil
+}
+
+func (t *TestServer) VoteVolume(ctx context.Context, req *playsource.VoteVolumeRequest) (*playsource.VoteVolumeResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.volumeVoter.vote(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Changed {
+		t.volumeChanged()
+	}
+
+	return resp, nil
+}
+
+func (t *TestServer) volumeChanged() {
+	t.volumeLock.Lock()
+	e := &playsource.PlaybackEvent{
+		Type:   playsource.PlaybackEvent_VOLUME_CHANGED,
+		Volume: int32(t.volume),
+		Muted:  t.muted,
+	}
+	t.volumeLock.Unlock()
+
+	t.events.publish(e)
+}
+
+func (t *TestServer) GetPlaybackSettings(ctx context.Context, req *playsource.GetPlaybackSettingsRequest) (*playsource.GetPlaybackSettingsResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	settings := t.settings.get()
+	return &playsource.GetPlaybackSettingsResponse{Settings: &settings}, nil
+}
+
+// SetPlaybackSettings only records the settings, since
+// there's no audio to fade.
+func (t *TestServer) SetPlaybackSettings(ctx context.Context, req *playsource.SetPlaybackSettingsRequest) (*playsource.SetPlaybackSettingsResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	if err := validatePlaybackSettings(req.Settings); err != nil {
+		return nil, err
+	}
+
+	t.settings.set(*req.Settings)
+	return &playsource.SetPlaybackSettingsResponse{}, nil
+}
+
+// track returns the track that plays song, which is
+// the track Search would have returned for it.
+func (t *TestServer) track(song playsource.Song) *playsource.Track {
+	return &playsource.Track{
+		Uri:      "test:track:" + song.Name,
+		Name:     song.Name,
+		Artists:  song.Artists,
+		LengthMs: int32(t.length(song.Name) / time.Millisecond),
+	}
+}
+
+// length returns the length of the track named name. It's drawn from
+// the length distribution with a source seeded by the name, so the
+// track is always the same length. Like mopidy's, lengths are in whole
+// milliseconds.
+func (t *TestServer) length(name string) time.Duration {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	length := t.songLength + time.Duration(r.NormFloat64()*float64(t.songLengthStdDev))
+	if min := t.songLength / 10; length < min {
+		length = min
+	}
+
+	return length / time.Millisecond * time.Millisecond
+}
+
+func (t *TestServer) trackEvent(eventType playsource.PlaybackEvent_Type, song playsource.Song) *playsource.PlaybackEvent {
+	return &playsource.PlaybackEvent{
+		Type:  eventType,
+		Song:  &song,
+		Track: t.track(song),
+	}
+}
+
+// Search pretends every search finds exactly one track, built from the request.
+func (t *TestServer) Search(ctx context.Context, req *playsource.SearchRequest) (*playsource.SearchResponse, error) {
+	if err := t.faults.inject(); err != nil {
+		return nil, err
+	}
+
+	if _, err := searchArgs(req); err != nil {
+		return nil, err
+	}
+
+	name := req.TrackName
+	if name == "" {
+		name = req.Any
+	}
+
+	track := mopidy.Track{
+		Name:   name,
+		URI:    "test:track:" + name,
+		Length: int(t.length(name) / time.Millisecond),
+	}
+	for _, a := range req.Artists {
+		track.Artists = append(track.Artists, mopidy.Artist{Name: a})
+	}
+
+	return searchResponse(req, []mopidy.Track{track}), nil
+}
+
+func (t *TestServer) GetPlayHistory(req *playsource.GetPlayHistoryRequest, stream playsource.Playsource_GetPlayHistoryServer) error {
+	if err := t.faults.inject(); err != nil {
+		return err
+	}
+
+	return sendHistory(t.history, req, stream)
+}
+
+func (t *TestServer) WatchPlayback(req *playsource.WatchPlaybackRequest, stream playsource.Playsource_WatchPlaybackServer) error {
+	if err := t.faults.inject(); err != nil {
+		return err
+	}
+
+	return streamEvents(t.events, req, stream)
+}
+
+func (t *TestServer) GetFaults(ctx context.Context, req *playsource.GetFaultsRequest) (*playsource.GetFaultsResponse, error) {
+	faults := t.faults.get()
+	return &playsource.GetFaultsResponse{Faults: &faults}, nil
+}
+
+func (t *TestServer) SetFaults(ctx context.Context, req *playsource.SetFaultsRequest) (*playsource.SetFaultsResponse, error) {
+	if err := validateFaults(req.Faults); err != nil {
+		return nil, err
+	}
+
+	t.faults.set(*req.Faults)
+	return &playsource.SetFaultsResponse{}, nil
+}
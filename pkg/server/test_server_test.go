@@ -1,6 +1,7 @@
 package server
 
 import (
+	"io"
 	"log"
 	"net"
 	"strconv"
@@ -13,17 +14,28 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func TestQueueLoop(t *testing.T) {
 	lis, err := net.Listen("tcp", "localhost:0")
 	assert.NoError(t, err)
 
+	clock := NewFakeClock(time.Now())
+	s := NewTestServer(TestConfig{
+		MaxQueueSize: 10,
+		SongLength:   3 * time.Second,
+		Faults: playsource.Faults{
+			FoundProbability:   0.8,
+			FailureProbability: 0.1,
+		},
+		Clock: clock,
+	})
+	defer s.Close()
+	_, events := s.events.watch("", 0)
+
 	grpcServer := grpc.NewServer()
-	playsource.RegisterPlaySourceServer(
-		grpcServer,
-		NewTestServer(10, 0.8, 3*time.Second),
-	)
+	playsource.RegisterPlaysourceServer(grpcServer, s)
 
 	go grpcServer.Serve(lis)
 	defer grpcServer.Stop()
@@ -32,7 +44,7 @@ func TestQueueLoop(t *testing.T) {
 	assert.NoError(t, err)
 	defer conn.Close()
 
-	c := playsource.NewPlaySourceClient(conn)
+	c := playsource.NewPlaysourceClient(conn)
 
 	// The general approach here is that we can keep queueing up to
 	// a certain amount of songs (bounded by service). However, as a
@@ -44,6 +56,37 @@ func TestQueueLoop(t *testing.T) {
 	stream, err := c.QueueSong(context.Background())
 	assert.NoError(t, err)
 
+	// There are never more responses outstanding than songs in flight.
+	responses := make(chan *playsource.QueueSongResponse, 3)
+	go func() {
+		defer close(responses)
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			responses <- resp
+		}
+	}()
+
+	// Songs play out as soon as they start, rather than
+	// keeping us waiting.
+	recv := func() *playsource.QueueSongResponse {
+		for {
+			select {
+			case resp, ok := <-responses:
+				require.True(t, ok, "Stream closed")
+				return resp
+			case e, ok := <-events:
+				require.True(t, ok, "Fell behind on events")
+				if e.Type == playsource.PlaybackEvent_TRACK_STARTED {
+					clock.BlockUntil(1)
+					clock.Advance(3 * time.Second)
+				}
+			}
+		}
+	}
+
 	var inFlight int
 
 	for i := 0; i < 100; {
@@ -61,14 +104,321 @@ func TestQueueLoop(t *testing.T) {
 			inFlight++
 		}
 
-		resp, err := stream.Recv()
-		require.NoError(t, err)
-
+		resp := recv()
 		inFlight--
-		if resp.Finished {
+		if resp.Failed {
+			log.Printf("Song %v failed: %v", resp.SongId, resp.Error)
+		} else if resp.Finished {
 			log.Printf("Song %v finished playing", resp.SongId)
 		} else if !resp.Found {
 			log.Println("could not find song:", resp.SongId)
 		}
 	}
 }
+
+// serveTest serves a TestServer, returning a connection to it.
+func serveTest(t *testing.T, config TestConfig) (*TestServer, *grpc.ClientConn, func()) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	s := NewTestServer(config)
+	grpcServer := grpc.NewServer()
+	playsource.RegisterPlaysourceServer(grpcServer, s)
+	playsource.RegisterTestControlServer(grpcServer, s)
+	go grpcServer.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+
+	return s, conn, func() {
+		conn.Close()
+		grpcServer.Stop()
+		s.Close()
+	}
+}
+
+// handshake opens a QueueSong stream, returning the handshake's response.
+func handshake(t *testing.T, c playsource.PlaysourceClient, h *playsource.Handshake) (playsource.Playsource_QueueSongClient, *playsource.QueueSongResponse, error) {
+	stream, err := c.QueueSong(context.Background())
+	require.NoError(t, err)
+
+	err = stream.Send(&playsource.QueueSongRequest{Handshake: h})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	return stream, resp, err
+}
+
+// nextEvent skips ahead to the next event of the expected type.
+func nextEvent(t *testing.T, events chan *playsource.PlaybackEvent, expected playsource.PlaybackEvent_Type) *playsource.PlaybackEvent {
+	for {
+		select {
+		case e := <-events:
+			if e.Type == expected {
+				return e
+			}
+		case <-time.After(time.Second):
+			require.FailNow(t, "Missing event", expected.String())
+		}
+	}
+}
+
+func TestTestServerLease(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	_, conn, stop := serveTest(t, TestConfig{
+		MaxQueueSize: 10,
+		LeaseTimeout: time.Minute,
+		Clock:        clock,
+	})
+	defer stop()
+
+	c := playsource.NewPlaysourceClient(conn)
+
+	primary, first, err := handshake(t, c, &playsource.Handshake{Controller: "a"})
+	require.NoError(t, err)
+
+	_, _, err = handshake(t, c, &playsource.Handshake{Controller: "b"})
+	assert.Equal(t, codes.Unavailable, grpc.Code(err))
+
+	_, second, err := handshake(t, c, &playsource.Handshake{Controller: "b", Takeover: true})
+	assert.NoError(t, err)
+
+	_, err = primary.Recv()
+	assert.Equal(t, codes.Aborted, grpc.Code(err))
+
+	// Only the primary can change the queue.
+	_, err = c.ClearQueue(context.Background(), &playsource.ClearQueueRequest{LeaseToken: first.Lease.Token})
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(err))
+	_, err = c.ClearQueue(context.Background(), &playsource.ClearQueueRequest{})
+	assert.Equal(t, codes.PermissionDenied, grpc.Code(err))
+	_, err = c.ClearQueue(context.Background(), &playsource.ClearQueueRequest{LeaseToken: second.Lease.Token})
+	assert.NoError(t, err)
+
+	// Once b stops heartbeating, its lease can be acquired.
+	clock.Advance(time.Minute)
+	_, _, err = handshake(t, c, &playsource.Handshake{Controller: "c"})
+	assert.NoError(t, err)
+}
+
+func TestTestServerPlaybackEvents(t *testing.T) {
+	s := NewTestServer(TestConfig{
+		MaxQueueSize: 10,
+		SongLength:   time.Minute,
+		Clock:        NewFakeClock(time.Now()),
+	})
+	defer s.Close()
+
+	_, events := s.events.watch("", 0)
+	s.enqueue(playsource.QueueSongRequest{Song: &playsource.Song{SongId: 1, Name: "1"}})
+	nextEvent(t, events, playsource.PlaybackEvent_TRACK_STARTED)
+
+	s.command(playbackCommand{action: pauseAction})
+	assert.Equal(t, playsource.PlayState_PAUSED, nextEvent(t, events, playsource.PlaybackEvent_PAUSED).State)
+	s.command(playbackCommand{action: resumeAction})
+	assert.Equal(t, playsource.PlayState_PLAYING, nextEvent(t, events, playsource.PlaybackEvent_RESUMED).State)
+	s.command(playbackCommand{action: stopAction})
+	assert.Equal(t, playsource.PlayState_STOPPED, nextEvent(t, events, playsource.PlaybackEvent_STOPPED).State)
+
+	// Playback also stops once the queue runs out.
+	s.command(playbackCommand{action: resumeAction})
+	s.command(playbackCommand{action: skipAction})
+	nextEvent(t, events, playsource.PlaybackEvent_TRACK_FINISHED)
+	assert.Equal(t, playsource.PlayState_STOPPED, nextEvent(t, events, playsource.PlaybackEvent_STOPPED).State)
+}
+
+func TestTestServerSessions(t *testing.T) {
+	s, conn, stop := serveTest(t, TestConfig{
+		MaxQueueSize: 10,
+		SongLength:   time.Minute,
+		LeaseTimeout: time.Minute,
+		Faults:       playsource.Faults{FoundProbability: 1},
+		Clock:        NewFakeClock(time.Now()),
+	})
+	defer stop()
+
+	c := playsource.NewPlaysourceClient(conn)
+
+	_, events := s.events.watch("", 0)
+	queue := func(stream playsource.Playsource_QueueSongClient, id int32) {
+		err := stream.Send(&playsource.QueueSongRequest{Song: &playsource.Song{SongId: id, Name: strconv.Itoa(int(id))}})
+		require.NoError(t, err)
+	}
+
+	disconnect := func(stream playsource.Playsource_QueueSongClient) {
+		require.NoError(t, stream.CloseSend())
+		_, err := stream.Recv()
+		require.Equal(t, io.EOF, err)
+	}
+
+	stream, resp, err := handshake(t, c, &playsource.Handshake{Controller: "a"})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Session)
+	require.NotEmpty(t, resp.Session.Id)
+	assert.False(t, resp.Session.Resumed)
+	id := resp.Session.Id
+
+	queue(stream, 1)
+	nextEvent(t, events, playsource.PlaybackEvent_TRACK_STARTED)
+	queue(stream, 2)
+	nextEvent(t, events, playsource.PlaybackEvent_QUEUE_CHANGED)
+	queue(stream, 3)
+	nextEvent(t, events, playsource.PlaybackEvent_QUEUE_CHANGED)
+
+	// The first song finishes while the primary is disconnected.
+	disconnect(stream)
+	s.command(playbackCommand{action: skipAction})
+	nextEvent(t, events, playsource.PlaybackEvent_TRACK_STARTED)
+
+	// Resuming the session replays it, and reports what's in flight.
+	stream, resp, err = handshake(t, c, &playsource.Handshake{Controller: "a", SessionId: id})
+	require.NoError(t, err)
+	assert.True(t, resp.Session.Resumed)
+	var inFlight []int32
+	for _, song := range resp.Session.InFlight {
+		inFlight = append(inFlight, song.SongId)
+	}
+	assert.Equal(t, []int32{1, 2, 3}, inFlight)
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	assert.True(t, resp.Skipped)
+	assert.Equal(t, int32(1), resp.SongId)
+
+	// A new primary starts over, without the previous session's songs.
+	disconnect(stream)
+	s.command(playbackCommand{action: skipAction})
+	nextEvent(t, events, playsource.PlaybackEvent_TRACK_STARTED)
+
+	stream, resp, err = handshake(t, c, &playsource.Handshake{Controller: "b"})
+	require.NoError(t, err)
+	assert.False(t, resp.Session.Resumed)
+	assert.NotEqual(t, id, resp.Session.Id)
+	assert.Empty(t, resp.Session.InFlight)
+
+	list, err := c.ListQueue(context.Background(), &playsource.ListQueueRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Entries)
+
+	queue(stream, 4)
+	nextEvent(t, events, playsource.PlaybackEvent_TRACK_STARTED)
+	s.command(playbackCommand{action: skipAction})
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), resp.SongId)
+}
+
+func TestTestServerFaults(t *testing.T) {
+	// Songs only finish when they're skipped.
+	s, conn, stop := serveTest(t, TestConfig{
+		MaxQueueSize: 10,
+		SongLength:   10 * time.Second,
+		Faults:       playsource.Faults{FoundProbability: 1},
+		Clock:        NewFakeClock(time.Now()),
+	})
+	defer stop()
+	_, events := s.events.watch("", 0)
+
+	c := playsource.NewPlaysourceClient(conn)
+	control := playsource.NewTestControlClient(conn)
+
+	_, err := control.SetFaults(context.Background(), &playsource.SetFaultsRequest{
+		Faults: &playsource.Faults{FoundProbability: 1, FailingSongIds: []int32{1}},
+	})
+	require.NoError(t, err)
+
+	stream, _, err := handshake(t, c, &playsource.Handshake{Controller: "a"})
+	require.NoError(t, err)
+
+	for id := int32(1); id <= 2; id++ {
+		err := stream.Send(&playsource.QueueSongRequest{
+			Song: &playsource.Song{SongId: id, Name: strconv.Itoa(int(id))},
+		})
+		require.NoError(t, err)
+	}
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), resp.SongId)
+	assert.True(t, resp.Failed)
+
+	for started := false; !started; {
+		select {
+		case e := <-events:
+			started = e.Type == playsource.PlaybackEvent_TRACK_STARTED && e.Song.SongId == 2
+		case <-time.After(time.Second):
+			require.FailNow(t, "Song 2 never started")
+		}
+	}
+
+	// A stale skip leaves the song playing.
+	_, err = c.SkipSong(context.Background(), &playsource.SkipSongRequest{
+		Expected: &playsource.Song{SongId: 1},
+	})
+	assert.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+
+	skip, err := c.SkipSong(context.Background(), &playsource.SkipSongRequest{
+		Expected: &playsource.Song{SongId: 2},
+	})
+	require.NoError(t, err)
+	assert.True(t, skip.Skipped)
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.SongId)
+	assert.True(t, resp.Skipped)
+	assert.False(t, resp.Finished)
+
+	_, err = control.SetFaults(context.Background(), &playsource.SetFaultsRequest{
+		Faults: &playsource.Faults{ErrorProbability: 1},
+	})
+	require.NoError(t, err)
+
+	_, err = c.GetVolume(context.Background(), &playsource.GetVolumeRequest{})
+	assert.Equal(t, codes.Unavailable, grpc.Code(err))
+}
+
+func TestTestServerHourLongPlaylist(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	_, conn, stop := serveTest(t, TestConfig{
+		MaxQueueSize:     20,
+		SongLength:       3 * time.Minute,
+		SongLengthStdDev: time.Minute,
+		Faults:           playsource.Faults{FoundProbability: 1},
+		Clock:            clock,
+	})
+	defer stop()
+
+	c := playsource.NewPlaysourceClient(conn)
+	stream, _, err := handshake(t, c, &playsource.Handshake{Controller: "a"})
+	require.NoError(t, err)
+
+	var total time.Duration
+	for id := int32(0); id < 20; id++ {
+		err := stream.Send(&playsource.QueueSongRequest{
+			Song: &playsource.Song{SongId: id, Name: strconv.Itoa(int(id))},
+		})
+		require.NoError(t, err)
+	}
+
+	start := clock.Now()
+	for id := int32(0); id < 20; id++ {
+		// Wait for the song to start, then let it play out.
+		clock.BlockUntil(1)
+		playing, err := c.GetPlaying(context.Background(), &playsource.GetPlayingRequest{})
+		require.NoError(t, err)
+		require.Equal(t, id, playing.Song.SongId)
+
+		length := time.Duration(playing.LengthMs) * time.Millisecond
+		total += length
+		clock.Advance(length)
+
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, id, resp.SongId)
+		assert.True(t, resp.Finished)
+	}
+
+	assert.Equal(t, total, clock.Now().Sub(start))
+	assert.True(t, total > 40*time.Minute)
+}
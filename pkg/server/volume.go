@@ -0,0 +1,111 @@
+package server
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+// VolumeVoteConfig configures how listeners vote on the volume.
+type VolumeVoteConfig struct {
+	// Net votes (up minus down) needed to change the volume.
+	Threshold int
+
+	// How much the volume changes by when the threshold is reached.
+	Step int
+
+	// Votes expire after Window.
+	Window time.Duration
+}
+
+var DefaultVolumeVoteConfig = VolumeVoteConfig{
+	Threshold: 3,
+	Step:      10,
+	Window:    1 * time.Minute,
+}
+
+func validateVolume(volume int32) error {
+	if volume < 0 || volume > 100 {
+		return errf(codes.InvalidArgument, "Volume must be between 0 and 100")
+	}
+
+	return nil
+}
+
+// volumeVoter changes the volume once enough listeners vote for it.
+type volumeVoter struct {
+	config VolumeVoteConfig
+	votes  *votes
+
+	getVolume func(context.Context) (int, error)
+	setVolume func(context.Context, int) error
+}
+
+func newVolumeVoter(config VolumeVoteConfig, clock Clock, getVolume func(context.Context) (int, error), setVolume func(context.Context, int) error) *volumeVoter {
+	return &volumeVoter{
+		config:    config,
+		votes:     newVotes(config.Window, clock),
+		getVolume: getVolume,
+		setVolume: setVolume,
+	}
+}
+
+func (v *volumeVoter) vote(ctx context.Context, req *playsource.VoteVolumeRequest) (*playsource.VoteVolumeResponse, error) {
+	var value int
+	switch req.Direction {
+	case playsource.VoteVolumeRequest_UP:
+		value = 1
+	case playsource.VoteVolumeRequest_DOWN:
+		value = -1
+	default:
+		return nil, errf(codes.InvalidArgument, "Direction must be UP or DOWN")
+	}
+
+	if req.Voter == "" {
+		return nil, errf(codes.InvalidArgument, "Voter must be specified")
+	}
+
+	total := v.votes.vote(req.Voter, value)
+
+	volume, err := v.getVolume(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	resp := &playsource.VoteVolumeResponse{
+		Volume:    int32(volume),
+		Votes:     int32(total),
+		Threshold: int32(v.config.Threshold),
+	}
+
+	if total < v.config.Threshold && total > -v.config.Threshold {
+		return resp, nil
+	}
+
+	if total > 0 {
+		volume += v.config.Step
+	} else {
+		volume -= v.config.Step
+	}
+
+	if volume > 100 {
+		volume = 100
+	} else if volume < 0 {
+		volume = 0
+	}
+
+	if err := v.setVolume(ctx, volume); err != nil {
+		return nil, backendError(err)
+	}
+
+	v.votes.reset()
+
+	resp.Volume = int32(volume)
+	resp.Changed = true
+	resp.Votes = 0
+
+	return resp, nil
+}
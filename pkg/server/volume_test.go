@@ -0,0 +1,76 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+func TestVolumeVoter(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	volume := 50
+	var mixerErr error
+	v := newVolumeVoter(
+		VolumeVoteConfig{Threshold: 2, Step: 10, Window: time.Minute},
+		clock,
+		func(context.Context) (int, error) { return volume, mixerErr },
+		func(ctx context.Context, v int) error {
+			volume = v
+			return mixerErr
+		},
+	)
+
+	vote := func(voter string, direction playsource.VoteVolumeRequest_Direction) *playsource.VoteVolumeResponse {
+		resp, err := v.vote(context.Background(), &playsource.VoteVolumeRequest{Voter: voter, Direction: direction})
+		require.NoError(t, err)
+		return resp
+	}
+
+	_, err := v.vote(context.Background(), &playsource.VoteVolumeRequest{Direction: playsource.VoteVolumeRequest_UP})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+	_, err = v.vote(context.Background(), &playsource.VoteVolumeRequest{Voter: "a"})
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+
+	// Voting twice doesn't count twice.
+	for i := 0; i < 2; i++ {
+		resp := vote("a", playsource.VoteVolumeRequest_UP)
+		assert.False(t, resp.Changed)
+		assert.Equal(t, int32(1), resp.Votes)
+		assert.Equal(t, int32(2), resp.Threshold)
+		assert.Equal(t, int32(50), resp.Volume)
+	}
+
+	// Votes expire.
+	clock.Advance(time.Minute)
+	assert.Equal(t, int32(1), vote("b", playsource.VoteVolumeRequest_UP).Votes)
+
+	// Reaching the threshold changes the volume, and starts the votes over.
+	resp := vote("c", playsource.VoteVolumeRequest_UP)
+	assert.True(t, resp.Changed)
+	assert.Equal(t, int32(60), resp.Volume)
+	assert.Equal(t, int32(0), resp.Votes)
+	assert.Equal(t, 60, volume)
+
+	// Opposing votes cancel out, and changing a vote replaces it.
+	vote("a", playsource.VoteVolumeRequest_UP)
+	assert.Equal(t, int32(0), vote("b", playsource.VoteVolumeRequest_DOWN).Votes)
+
+	// The volume doesn't go below 0.
+	volume = 5
+	resp = vote("a", playsource.VoteVolumeRequest_DOWN)
+	assert.True(t, resp.Changed)
+	assert.Equal(t, int32(0), resp.Volume)
+
+	// Without a mixer, the volume can't be voted on.
+	mixerErr = &mopidy.NoMixerError{Method: "core.mixer.get_volume"}
+	_, err = v.vote(context.Background(), &playsource.VoteVolumeRequest{Voter: "a", Direction: playsource.VoteVolumeRequest_UP})
+	assert.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+}
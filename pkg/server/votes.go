@@ -0,0 +1,57 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+type vote struct {
+	value int
+	cast  time.Time
+}
+
+// votes tallies votes from distinct voters. Only a voter's latest
+// vote counts, and votes expire after window.
+type votes struct {
+	window time.Duration
+	clock  Clock
+
+	lock sync.Mutex
+	cast map[string]vote
+}
+
+func newVotes(window time.Duration, clock Clock) *votes {
+	return &votes{
+		window: window,
+		clock:  clock,
+		cast:   make(map[string]vote),
+	}
+}
+
+// vote casts voter's vote, and returns the sum of all current votes.
+func (v *votes) vote(voter string, value int) int {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	now := v.clock.Now()
+	v.cast[voter] = vote{value: value, cast: now}
+
+	var total int
+	for voter, c := range v.cast {
+		if now.Sub(c.cast) >= v.window {
+			delete(v.cast, voter)
+			continue
+		}
+
+		total += c.value
+	}
+
+	return total
+}
+
+func (v *votes) reset() {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.cast = make(map[string]vote)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"io"
+	"log"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/crowdsoundsystem/playsource/pkg/mopidy"
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+)
+
+// eventBufferSize is how many events are kept around for
+// watchers that reconnect.
+const eventBufferSize = 1024
+
+// eventLog sequences playback events, buffering the most
+// recent ones, and fans them out to watchers.
+type eventLog struct {
+	clock Clock
+
+	// epoch tells this log's sequence numbers apart from those
+	// of earlier runs, which start over from 1.
+	epoch string
+
+	lock     sync.Mutex
+	sequence uint64
+	buffer   []*playsource.PlaybackEvent
+	watchers map[chan *playsource.PlaybackEvent]struct{}
+}
+
+func newEventLog(clock Clock) *eventLog {
+	return &eventLog{
+		clock:    clock,
+		epoch:    strconv.FormatInt(clock.Now().UnixNano(), 36),
+		watchers: make(map[chan *playsource.PlaybackEvent]struct{}),
+	}
+}
+
+// publish assigns e the next sequence number, and sends it to every
+// watcher. Watchers that aren't keeping up are closed, rather than
+// holding up the publisher.
+func (l *eventLog) publish(e *playsource.PlaybackEvent) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.sequence++
+	e.Sequence = l.sequence
+	e.Epoch = l.epoch
+	e.TimestampMs = toMillis(l.clock.Now())
+
+	l.buffer = append(l.buffer, e)
+	if len(l.buffer) > eventBufferSize {
+		l.buffer = l.buffer[len(l.buffer)-eventBufferSize:]
+	}
+
+	for c := range l.watchers {
+		select {
+		case c <- e:
+		default:
+			log.Println("Watcher is falling behind, closing")
+			delete(l.watchers, c)
+			close(c)
+		}
+	}
+}
+
+// watch returns the buffered events after sequence, and a channel
+// of the events that follow them. If epoch is set, it's the epoch
+// the sequence is from.
+func (l *eventLog) watch(epoch string, after uint64) ([]*playsource.PlaybackEvent, chan *playsource.PlaybackEvent) {
+	c := make(chan *playsource.PlaybackEvent, 64)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.watchers[c] = struct{}{}
+
+	// A sequence from another epoch, or from the future, means
+	// we've restarted since the watcher last saw us.
+	if after == 0 {
+		return nil, c
+	} else if (epoch != "" && epoch != l.epoch) || after > l.sequence {
+		after = 0
+	}
+
+	var replay []*playsource.PlaybackEvent
+	for _, e := range l.buffer {
+		if e.Sequence > after {
+			replay = append(replay, e)
+		}
+	}
+
+	return replay, c
+}
+
+func (l *eventLog) unwatch(c chan *playsource.PlaybackEvent) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.watchers[c]; ok {
+		delete(l.watchers, c)
+		close(c)
+	}
+}
+
+// streamEvents serves a WatchPlayback stream from l.
+func streamEvents(l *eventLog, req *playsource.WatchPlaybackRequest, stream playsource.Playsource_WatchPlaybackServer) error {
+	replay, events := l.watch(req.Epoch, req.AfterSequence)
+	defer l.unwatch(events)
+
+	for _, e := range replay {
+		err := stream.Send(e)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return errf(codes.ResourceExhausted, "Watcher fell behind")
+			}
+
+			err := stream.Send(e)
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// trackEvent describes an event about track. If the track was
+// queued through the playsource, song is the song it was queued for.
+func trackEvent(t playsource.PlaybackEvent_Type, song *playsource.Song, track mopidy.Track) *playsource.PlaybackEvent {
+	return &playsource.PlaybackEvent{
+		Type:  t,
+		Song:  song,
+		Track: trackInfo(track),
+	}
+}
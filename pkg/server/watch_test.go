@@ -0,0 +1,67 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/crowdsoundsystem/playsource/pkg/playsource"
+	"github.com/stretchr/testify/assert"
+)
+
+func sequences(events []*playsource.PlaybackEvent) []uint64 {
+	var s []uint64
+	for _, e := range events {
+		s = append(s, e.Sequence)
+	}
+	return s
+}
+
+func TestEventLogReplay(t *testing.T) {
+	l := newEventLog(RealClock)
+	for i := 0; i < 3; i++ {
+		l.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_QUEUE_CHANGED})
+	}
+
+	// New watchers only see new events.
+	replay, c := l.watch("", 0)
+	assert.Empty(t, replay)
+	l.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_PAUSED})
+	e := <-c
+	assert.Equal(t, uint64(4), e.Sequence)
+	assert.Equal(t, l.epoch, e.Epoch)
+	l.unwatch(c)
+
+	// Resuming watchers see what they missed.
+	replay, c = l.watch(l.epoch, 2)
+	assert.Equal(t, []uint64{3, 4}, sequences(replay))
+	l.unwatch(c)
+
+	// Watchers from before a restart see everything, even
+	// if we've since caught up to where they were.
+	replay, c = l.watch("earlier", 2)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, sequences(replay))
+	l.unwatch(c)
+
+	// Without an epoch, only sequences from the future give it away.
+	replay, c = l.watch("", 10)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, sequences(replay))
+	l.unwatch(c)
+}
+
+func TestEventLogSlowWatcher(t *testing.T) {
+	l := newEventLog(RealClock)
+	_, c := l.watch("", 0)
+
+	for i := 0; i <= cap(c); i++ {
+		l.publish(&playsource.PlaybackEvent{Type: playsource.PlaybackEvent_QUEUE_CHANGED})
+	}
+
+	// The watcher gets what was buffered, and is then closed.
+	for i := 0; i < cap(c); i++ {
+		<-c
+	}
+	_, ok := <-c
+	assert.False(t, ok)
+
+	// Unwatching after being closed is fine.
+	l.unwatch(c)
+}